@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/rajindersingh041/go-microservices/internal/httpx"
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+// Replays a time range of events from one Postgres store into another
+// ingestion-service, batch by batch. Progress is saved after each batch so
+// an interrupted run picks up where it left off.
+
+var (
+	sourceDSN  = flag.String("source-dsn", os.Getenv("REPLAY_SOURCE_DSN"), "Postgres DSN of the store to read from (default $REPLAY_SOURCE_DSN)")
+	destURL    = flag.String("dest-url", "http://localhost:8080/ingest", "Ingestion service URL to forward to")
+	fromFlag   = flag.String("from", "", "Start of the range, RFC3339, inclusive (default: oldest event)")
+	toFlag     = flag.String("to", "", "End of the range, RFC3339, inclusive (default: now)")
+	batchSize  = flag.Int("batch-size", 1000, "Events per forwarded batch")
+	rate       = flag.Float64("rate", 0, "Max batches per second; 0 means unlimited")
+	cursorFile = flag.String("cursor-file", "replay.cursor", "File holding the resume cursor; empty disables resuming")
+	resume     = flag.Bool("resume", false, "Resume from the cursor file even if it was saved by a run with other -from, -to, source or destination")
+
+	// POSTs to /ingest aren't idempotent, so the client doesn't retry;
+	// a failed batch stops the run and is resent from the cursor
+	client = httpx.NewClient(httpx.Options{Timeout: 30 * time.Second})
+)
+
+// replaySQL pages by (Timestamp, id) so rows sharing a timestamp are neither
+// skipped nor repeated across batch boundaries.
+const replaySQL = `
+SELECT id, Timestamp, COALESCE(Level, ''), COALESCE(Source, ''), COALESCE(Message, '')
+FROM events
+WHERE (Timestamp, id) > ($1, $2) AND Timestamp <= $3
+ORDER BY Timestamp, id
+LIMIT $4`
+
+// cursor is the position of the last forwarded row.
+type cursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// replayRun identifies a run, so a cursor saved by one isn't silently
+// picked up by another. The source is recorded without its password.
+type replayRun struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Source string `json:"source"`
+	Dest   string `json:"dest"`
+}
+
+// cursorState is the cursor file: the run it belongs to and how far it got.
+type cursorState struct {
+	replayRun
+	Timestamp time.Time `json:"timestamp"`
+	ID        int64     `json:"id"`
+}
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Printf("Replay failed: %v", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if *sourceDSN == "" {
+		return errors.New("-source-dsn (or REPLAY_SOURCE_DSN) is required")
+	}
+	if *batchSize <= 0 {
+		return fmt.Errorf("-batch-size must be positive, got %d", *batchSize)
+	}
+
+	from, err := parseFlagTime(*fromFlag, time.Time{})
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	to, err := parseFlagTime(*toFlag, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	srcCfg, err := pgx.ParseConfig(*sourceDSN)
+	if err != nil {
+		return fmt.Errorf("invalid -source-dsn: %w", err)
+	}
+	// -to is kept as given: an open-ended run resumes up to the new now
+	job := replayRun{
+		From:   *fromFlag,
+		To:     *toFlag,
+		Source: fmt.Sprintf("%s@%s:%d/%s", srcCfg.User, srcCfg.Host, srcCfg.Port, srcCfg.Database),
+		Dest:   *destURL,
+	}
+
+	// Start just before from, or resume after the saved cursor
+	pos := cursor{Timestamp: from}
+	if saved, ok, err := loadCursor(*cursorFile); err != nil {
+		return err
+	} else if ok {
+		if saved.replayRun != job && !*resume {
+			return fmt.Errorf("cursor file %s was saved by another run (%+v); pass -resume to continue from it anyway, or remove it to start over",
+				*cursorFile, saved.replayRun)
+		}
+		if saved.Timestamp.Before(from) || saved.Timestamp.After(to) {
+			return fmt.Errorf("cursor file %s is at %s, outside -from %s .. -to %s; remove it to start over",
+				*cursorFile, saved.Timestamp.Format(time.RFC3339Nano), from.Format(time.RFC3339), to.Format(time.RFC3339))
+		}
+		pos = cursor{Timestamp: saved.Timestamp, ID: saved.ID}
+		log.Printf("Resuming after cursor %s (id %d)", pos.Timestamp.Format(time.RFC3339Nano), pos.ID)
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, *sourceDSN)
+	if err != nil {
+		return fmt.Errorf("failed to connect to source: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	var throttle <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	log.Printf("--- Starting Replay ---")
+	log.Printf("Range: %s .. %s", pos.Timestamp.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+	log.Printf("Destination: %s (%d events/batch)", *destURL, *batchSize)
+
+	startTime := time.Now()
+	total, batches := 0, 0
+	for {
+		events, last, err := readBatch(ctx, conn, pos, to)
+		if err != nil {
+			return fmt.Errorf("failed to read batch after %d events: %w", total, err)
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		if throttle != nil {
+			<-throttle
+		}
+		if err := forward(events); err != nil {
+			return fmt.Errorf("failed to forward batch after %d events: %w", total, err)
+		}
+
+		pos = last
+		if err := saveCursor(*cursorFile, job, pos); err != nil {
+			return err
+		}
+		total += len(events)
+		batches++
+		log.Printf("Forwarded batch %d: %d events (total %d, cursor %s)",
+			batches, len(events), total, pos.Timestamp.Format(time.RFC3339Nano))
+	}
+
+	duration := time.Since(startTime)
+	log.Printf("--- Replay Complete ---")
+	log.Printf("Forwarded %d events in %d batches in %s (%.2f events/s)",
+		total, batches, duration, float64(total)/duration.Seconds())
+	return nil
+}
+
+// readBatch returns up to batchSize events after pos and the new cursor.
+func readBatch(ctx context.Context, conn *pgx.Conn, pos cursor, to time.Time) ([]models.Event, cursor, error) {
+	rows, err := conn.Query(ctx, replaySQL, pos.Timestamp, pos.ID, to, *batchSize)
+	if err != nil {
+		return nil, pos, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&pos.ID, &e.Timestamp, &e.Level, &e.Source, &e.Message); err != nil {
+			return nil, pos, err
+		}
+		pos.Timestamp = e.Timestamp
+		events = append(events, e)
+	}
+	return events, pos, rows.Err()
+}
+
+// forward posts one batch and requires a full 2xx acceptance. A 207 means
+// part of the batch was rejected, which would leave a gap, so it fails.
+func forward(events []models.Event) error {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(*destURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || resp.StatusCode == http.StatusMultiStatus {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("destination answered %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func parseFlagTime(v string, def time.Time) (time.Time, error) {
+	if v == "" {
+		return def, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// loadCursor reads the cursor file at path, if present.
+func loadCursor(path string) (cursorState, bool, error) {
+	if path == "" {
+		return cursorState{}, false, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cursorState{}, false, nil
+	}
+	if err != nil {
+		return cursorState{}, false, fmt.Errorf("failed to read cursor: %w", err)
+	}
+
+	var st cursorState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return cursorState{}, false, fmt.Errorf("malformed cursor file %s: %w", path, err)
+	}
+	return st, true, nil
+}
+
+// saveCursor writes the cursor via a rename so a crash never leaves it half written.
+func saveCursor(path string, job replayRun, c cursor) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(cursorState{replayRun: job, Timestamp: c.Timestamp, ID: c.ID})
+	if err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+// wantsIDs reports whether the client asked for per-event IDs in the
+// acknowledgement, with ?ack=ids or by sending an id on any event.
+func wantsIDs(r *http.Request, events []models.Event) bool {
+	if r.URL.Query().Get("ack") == "ids" {
+		return true
+	}
+	for _, e := range events {
+		if e.ID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// assignIDs gives every event without a client id a random UUID.
+func assignIDs(events []models.Event) {
+	for i := range events {
+		if events[i].ID == "" {
+			events[i].ID = newEventID()
+		}
+	}
+}
+
+// newEventID returns a random (version 4) UUID.
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// acceptedIDs lists the IDs of events not in failed, which must be sorted.
+func acceptedIDs(events []models.Event, failed []int) []string {
+	ids := make([]string, 0, len(events)-len(failed))
+	for i, e := range events {
+		if len(failed) > 0 && failed[0] == i {
+			failed = failed[1:]
+			continue
+		}
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
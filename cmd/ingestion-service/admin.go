@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/rajindersingh041/go-microservices/internal/database"
+)
+
+// requireAdmin rejects requests without "Authorization: Bearer <ADMIN_TOKEN>".
+// Admin routes are only registered when ADMIN_ENABLED is true.
+func (app *App) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(app.cfg.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleDeleteEvents removes every event from ?source, e.g. the rows a smoke
+// test leaves behind. The delete is synchronous; the response reports the
+// predicate used and how many rows went.
+func (app *App) handleDeleteEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		http.Error(w, "source is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.cfg.WriteTimeout)
+	defer cancel()
+
+	tag, err := app.db.Exec(ctx, "DELETE FROM events WHERE Source = $1", source)
+	if err != nil {
+		log.Printf("Error deleting events for source %q: %v", source, err)
+		writeError(ctx, w, err, "Server error during delete")
+		return
+	}
+
+	log.Printf("Admin delete removed %d events from source %q", tag.RowsAffected(), source)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "deleted",
+		"predicate": map[string]string{"source": source},
+		"deleted":   tag.RowsAffected(),
+	})
+}
+
+// handleInitSchema re-runs every schema migration, recreating tables that
+// were dropped by hand without restarting the service.
+func (app *App) handleInitSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.cfg.WriteTimeout)
+	defer cancel()
+
+	if err := database.Reapply(ctx, app.db); err != nil {
+		log.Printf("Admin schema re-init failed: %v", err)
+		writeError(ctx, w, err, "Schema init failed: "+err.Error())
+		return
+	}
+
+	log.Println("Admin schema re-init completed.")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
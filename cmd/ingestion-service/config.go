@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/config"
+)
+
+// Config holds the ingestion-service settings read from the environment.
+type Config struct {
+	// CopyChunk (POSTGRES_COPY_CHUNK) is the most rows sent per CopyFrom.
+	// A larger batch is copied in chunks inside one transaction, so it is
+	// still all-or-nothing. Defaults to 10000.
+	CopyChunk int
+
+	// MaxConcurrentCopy (POSTGRES_MAX_CONCURRENT_COPY) caps how many batch
+	// writes run at once; an ingest beyond it gets 503 with Retry-After.
+	// 0, the default, means no cap.
+	MaxConcurrentCopy int
+
+	// ChunkSize (INGEST_CHUNK_SIZE) splits a batch into CopyFrom calls of
+	// this many rows, salvaging good chunks and pinpointing bad rows.
+	// 0, the default, sends the whole batch in one CopyFrom.
+	ChunkSize int
+
+	// TimestampPolicy (INGEST_DEFAULT_TIMESTAMP) decides what happens to an
+	// event without a timestamp: "now" (default) stamps it with the ingest
+	// time, "reject" fails the request with 400.
+	TimestampPolicy string
+
+	// DefaultLevel (INGEST_DEFAULT_LEVEL) is given to events with an empty
+	// level, so level filters still find them. Defaults to INFO.
+	DefaultLevel string
+
+	// RequireLevel (INGEST_REQUIRE_LEVEL) rejects events with an empty
+	// level with 400 instead of defaulting them. Defaults to false.
+	RequireLevel bool
+
+	// SourceRate (INGEST_SOURCE_RATE) is how many events per second each
+	// source may ingest; a batch over budget gets 429. 0, the default,
+	// disables the limit.
+	SourceRate int
+
+	// SourceBurst (INGEST_SOURCE_BURST) is how many events a source may
+	// send at once above SourceRate, and so also its largest batch.
+	// Defaults to SourceRate.
+	SourceBurst int
+
+	// CommitRetries (INGEST_COMMIT_RETRIES) is how many more times a batch
+	// write is attempted after a transient failure before answering 503.
+	// Defaults to 0.
+	CommitRetries int
+
+	// WriteTimeout (INGEST_WRITE_TIMEOUT) bounds each request's database
+	// write, and each batch flush of a gRPC stream; when it expires the
+	// write is abandoned with a 504 or DeadlineExceeded. Defaults to 15s.
+	WriteTimeout time.Duration
+
+	// AllowedSources (INGEST_ALLOWED_SOURCES) is a comma-separated
+	// allow-list of event sources, keyed by lower case and mapped to the
+	// configured spelling. Empty, the default, accepts any source.
+	AllowedSources map[string]string
+
+	// WaitTimeout (POSTGRES_WAIT_TIMEOUT) is how long startup waits for the
+	// Postgres port to accept connections before connecting. 0, the
+	// default, connects straight away.
+	WaitTimeout time.Duration
+
+	// HealthInterval (INGEST_HEALTH_INTERVAL) is how often the database is
+	// pinged in the background to notice a restart and re-init the schema.
+	// Defaults to 10s; 0 disables the check.
+	HealthInterval time.Duration
+
+	// AdminToken (ADMIN_TOKEN) is the bearer token the /admin endpoints
+	// require.
+	AdminToken string
+
+	// AdminEnabled (ADMIN_ENABLED) registers the /admin endpoints. Defaults
+	// to true when ADMIN_TOKEN is set; true without a token is an error.
+	AdminEnabled bool
+
+	// SuccessStatus (INGEST_SUCCESS_STATUS) is the status a fully accepted
+	// ingest answers with, for clients that treat 202 as a failure. Must be
+	// 200, 201 or 202: 207 already means a partial chunked ingest, and 204
+	// and 205 can't carry the JSON acknowledgement. Defaults to 202 Accepted.
+	SuccessStatus int
+}
+
+func loadConfig() (Config, error) {
+	var cfg Config
+	var err error
+
+	if cfg.CopyChunk, err = config.Int("POSTGRES_COPY_CHUNK", 10000); err != nil {
+		return cfg, err
+	}
+	if cfg.CopyChunk < 1 {
+		return cfg, fmt.Errorf("POSTGRES_COPY_CHUNK must be at least 1, got %d", cfg.CopyChunk)
+	}
+
+	if cfg.MaxConcurrentCopy, err = config.Int("POSTGRES_MAX_CONCURRENT_COPY", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxConcurrentCopy < 0 {
+		return cfg, fmt.Errorf("POSTGRES_MAX_CONCURRENT_COPY must not be negative, got %d", cfg.MaxConcurrentCopy)
+	}
+
+	if cfg.ChunkSize, err = config.Int("INGEST_CHUNK_SIZE", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.ChunkSize < 0 {
+		return cfg, fmt.Errorf("INGEST_CHUNK_SIZE must not be negative, got %d", cfg.ChunkSize)
+	}
+
+	cfg.TimestampPolicy = config.String("INGEST_DEFAULT_TIMESTAMP", timestampNow)
+	if cfg.TimestampPolicy != timestampNow && cfg.TimestampPolicy != timestampReject {
+		return cfg, fmt.Errorf("INGEST_DEFAULT_TIMESTAMP must be %q or %q, got %q", timestampNow, timestampReject, cfg.TimestampPolicy)
+	}
+
+	cfg.DefaultLevel = strings.TrimSpace(config.String("INGEST_DEFAULT_LEVEL", "INFO"))
+	if cfg.DefaultLevel == "" {
+		return cfg, fmt.Errorf("INGEST_DEFAULT_LEVEL must not be blank")
+	}
+	if cfg.RequireLevel, err = strconv.ParseBool(config.String("INGEST_REQUIRE_LEVEL", "false")); err != nil {
+		return cfg, fmt.Errorf("INGEST_REQUIRE_LEVEL must be true or false: %w", err)
+	}
+
+	if cfg.SourceRate, err = config.Int("INGEST_SOURCE_RATE", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.SourceRate < 0 {
+		return cfg, fmt.Errorf("INGEST_SOURCE_RATE must not be negative, got %d", cfg.SourceRate)
+	}
+	if cfg.SourceBurst, err = config.Int("INGEST_SOURCE_BURST", cfg.SourceRate); err != nil {
+		return cfg, err
+	}
+	if cfg.SourceRate > 0 && cfg.SourceBurst < 1 {
+		return cfg, fmt.Errorf("INGEST_SOURCE_BURST must be at least 1, got %d", cfg.SourceBurst)
+	}
+
+	if cfg.CommitRetries, err = config.Int("INGEST_COMMIT_RETRIES", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.CommitRetries < 0 {
+		return cfg, fmt.Errorf("INGEST_COMMIT_RETRIES must not be negative, got %d", cfg.CommitRetries)
+	}
+
+	if cfg.WriteTimeout, err = config.Duration("INGEST_WRITE_TIMEOUT", 15*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.WriteTimeout <= 0 {
+		return cfg, fmt.Errorf("INGEST_WRITE_TIMEOUT must be positive, got %s", cfg.WriteTimeout)
+	}
+
+	for _, src := range strings.Split(config.String("INGEST_ALLOWED_SOURCES", ""), ",") {
+		if src = strings.TrimSpace(src); src != "" {
+			if cfg.AllowedSources == nil {
+				cfg.AllowedSources = make(map[string]string)
+			}
+			cfg.AllowedSources[strings.ToLower(src)] = src
+		}
+	}
+
+	if cfg.WaitTimeout, err = config.Duration("POSTGRES_WAIT_TIMEOUT", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.WaitTimeout < 0 {
+		return cfg, fmt.Errorf("POSTGRES_WAIT_TIMEOUT must not be negative, got %s", cfg.WaitTimeout)
+	}
+
+	if cfg.HealthInterval, err = config.Duration("INGEST_HEALTH_INTERVAL", 10*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.HealthInterval < 0 {
+		return cfg, fmt.Errorf("INGEST_HEALTH_INTERVAL must not be negative, got %s", cfg.HealthInterval)
+	}
+
+	cfg.AdminToken = config.String("ADMIN_TOKEN", "")
+	if cfg.AdminEnabled, err = strconv.ParseBool(config.String("ADMIN_ENABLED", strconv.FormatBool(cfg.AdminToken != ""))); err != nil {
+		return cfg, fmt.Errorf("ADMIN_ENABLED must be true or false: %w", err)
+	}
+	if cfg.AdminEnabled && cfg.AdminToken == "" {
+		return cfg, fmt.Errorf("ADMIN_ENABLED requires ADMIN_TOKEN to be set")
+	}
+
+	if cfg.SuccessStatus, err = config.Int("INGEST_SUCCESS_STATUS", http.StatusAccepted); err != nil {
+		return cfg, err
+	}
+	switch cfg.SuccessStatus {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+	default:
+		return cfg, fmt.Errorf("INGEST_SUCCESS_STATUS must be 200, 201 or 202, got %d", cfg.SuccessStatus)
+	}
+	return cfg, nil
+}
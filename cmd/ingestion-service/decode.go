@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/rajindersingh041/go-microservices/internal/eventspb"
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+// Content types with their own ingest path; anything else is JSON.
+const (
+	protobufContentType = "application/x-protobuf"
+	formContentType     = "application/x-www-form-urlencoded"
+)
+
+// utf8BOM is stripped from JSON bodies; editors and copy-paste add it and
+// encoding/json rejects it as an invalid character.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// decodeError explains why an ingest body could not be decoded into events.
+// Malformed is true when the body is not valid JSON at all, with Offset
+// pointing at the byte where parsing failed; otherwise Type holds the
+// top-level JSON type that was received instead.
+type decodeError struct {
+	Malformed bool
+	Offset    int64
+	Hint      string
+	Type      string
+	Err       error
+}
+
+func (e *decodeError) Error() string {
+	if e.Malformed {
+		msg := fmt.Sprintf("malformed JSON at byte %d", e.Offset)
+		if e.Err != nil {
+			msg += ": " + e.Err.Error()
+		}
+		if e.Hint != "" {
+			msg += " (" + e.Hint + ")"
+		}
+		return msg
+	}
+	return fmt.Sprintf("JSON is valid but not an Event or array of Events (got %s)", e.Type)
+}
+
+func (e *decodeError) Unwrap() error { return e.Err }
+
+// decodeEvents is "smart" and handles both single and batch events.
+// It returns a *decodeError when the body matches neither shape.
+func decodeEvents(body []byte) ([]models.Event, error) {
+	body = bytes.TrimPrefix(body, utf8BOM)
+
+	// Validate up front so a syntax error carries its byte offset
+	var raw json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		de := &decodeError{Malformed: true, Err: err}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			de.Offset = syntaxErr.Offset
+			de.Hint = syntaxHint(body, syntaxErr.Offset)
+		}
+		return nil, de
+	}
+
+	// Try to unmarshal as an array (batch) first
+	var events []models.Event
+	err := json.Unmarshal(body, &events)
+	if err == nil {
+		return events, nil
+	}
+
+	// If it's not an array, try to unmarshal as a single object
+	var singleEvent models.Event
+	if err2 := json.Unmarshal(body, &singleEvent); err2 != nil {
+		return nil, &decodeError{Type: jsonType(body), Err: err2}
+	}
+	return []models.Event{singleEvent}, nil
+}
+
+// syntaxHint recognises a trailing comma, the most common hand-edit slip,
+// just before the byte where parsing failed.
+func syntaxHint(body []byte, offset int64) string {
+	if offset < 1 || offset > int64(len(body)) {
+		return ""
+	}
+	if c := body[offset-1]; c != ']' && c != '}' {
+		return ""
+	}
+	before := bytes.TrimRight(body[:offset-1], " \t\r\n")
+	if len(before) > 0 && before[len(before)-1] == ',' {
+		return "trailing comma before closing bracket?"
+	}
+	return ""
+}
+
+// jsonType reports the top-level type of a valid JSON document.
+func jsonType(body []byte) string {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) == 0 {
+		return "empty"
+	}
+	switch trimmed[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// mediaType returns the request's Content-Type without parameters.
+func mediaType(r *http.Request) string {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// isProtobuf reports whether the request body is a Protobuf EventBatch.
+func isProtobuf(r *http.Request) bool {
+	return mediaType(r) == protobufContentType
+}
+
+// isForm reports whether the event arrives as form fields, or as query
+// params on a request with no body, e.g. curl -X POST '/ingest?level=INFO&...'.
+// curl -d labels every body as a form, so a form-typed body that is a JSON
+// object or array still goes down the JSON path.
+func isForm(r *http.Request, body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	if mediaType(r) == formContentType {
+		return !looksLikeJSON(trimmed)
+	}
+	return len(trimmed) == 0 && r.URL.RawQuery != ""
+}
+
+// looksLikeJSON reports whether body is a valid JSON object or array.
+func looksLikeJSON(body []byte) bool {
+	return len(body) > 0 && (body[0] == '{' || body[0] == '[') && json.Valid(body)
+}
+
+// decodeFormEvent builds one event from level, source, message and an
+// optional RFC3339 timestamp. Form fields win over query params. A missing
+// timestamp is always stamped with now, whatever INGEST_DEFAULT_TIMESTAMP says.
+func decodeFormEvent(r *http.Request, body []byte, now time.Time) ([]models.Event, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("malformed form body: %w", err)
+	}
+	get := func(key string) string {
+		if v := form.Get(key); v != "" {
+			return v
+		}
+		return r.URL.Query().Get(key)
+	}
+
+	e := models.Event{
+		Timestamp: now,
+		Level:     get("level"),
+		Source:    get("source"),
+		Message:   get("message"),
+		ID:        get("id"),
+	}
+	if e.Source == "" || e.Message == "" {
+		return nil, errors.New("source and message are required")
+	}
+	if ts := get("timestamp"); ts != "" {
+		if e.Timestamp, err = time.Parse(time.RFC3339, ts); err != nil {
+			return nil, fmt.Errorf("timestamp %q is not RFC3339", ts)
+		}
+	}
+	return []models.Event{e}, nil
+}
+
+// decodeProtoEvents unmarshals an eventspb.EventBatch into models.Event values.
+func decodeProtoEvents(body []byte) ([]models.Event, error) {
+	var batch eventspb.EventBatch
+	if err := proto.Unmarshal(body, &batch); err != nil {
+		return nil, err
+	}
+
+	events := make([]models.Event, len(batch.GetEvents()))
+	for i, e := range batch.GetEvents() {
+		events[i] = e.ToModel()
+	}
+	return events, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rajindersingh041/go-microservices/internal/database"
+	"github.com/rajindersingh041/go-microservices/internal/eventspb"
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+const (
+	grpcPort = ":9090"
+
+	// grpcBatchSize is how many streamed events are buffered per CopyFrom
+	grpcBatchSize = 1000
+)
+
+// grpcServer implements eventspb.EventIngestServer on top of the same pool
+// the HTTP handler uses.
+type grpcServer struct {
+	eventspb.UnimplementedEventIngestServer
+	app *App
+}
+
+// IngestEvents buffers the client's stream and flushes it in batches, so one
+// long-lived stream costs a handful of CopyFrom calls instead of one per event.
+func (s *grpcServer) IngestEvents(stream eventspb.EventIngest_IngestEventsServer) error {
+	ctx := stream.Context()
+	summary := &eventspb.IngestSummary{}
+	// A flush never charges a source more than its burst, or it could never pass
+	flushAt := grpcBatchSize
+	if s.app.limiter != nil {
+		flushAt = min(flushAt, s.app.limiter.burst)
+	}
+	batch := make([]models.Event, 0, flushAt)
+	received := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		refund := func() {}
+		if s.app.limiter != nil {
+			var err error
+			if refund, err = s.app.limiter.allow(batch); err != nil {
+				s.app.stats.errors.Add(1)
+				return status.Error(codes.ResourceExhausted, err.Error())
+			}
+		}
+		if !s.app.acquireCopy() {
+			refund()
+			s.app.stats.errors.Add(1)
+			return status.Error(codes.Unavailable, "too many concurrent ingests, retry later")
+		}
+		// Each write is bounded like an HTTP one, so a stalled database
+		// can't pin the stream's connection forever
+		writeCtx, cancel := context.WithTimeout(ctx, s.app.cfg.WriteTimeout)
+		n, err := s.app.insertEvents(writeCtx, batch)
+		cancel()
+		s.app.releaseCopy()
+		if err != nil {
+			log.Printf("Error during gRPC batch insert: %v", err)
+			s.app.stats.errors.Add(1)
+			if errors.Is(writeCtx.Err(), context.DeadlineExceeded) {
+				return status.Error(codes.DeadlineExceeded, "timed out writing to the database")
+			}
+			if database.IsTransient(err) {
+				return status.Error(codes.Unavailable, "database unavailable")
+			}
+			return status.Error(codes.Internal, "server error during batch insert")
+		}
+		s.app.stats.record(n)
+		summary.Ingested += n
+		summary.Batches++
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		e, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			if err := flush(); err != nil {
+				return err
+			}
+			log.Printf("Successfully ingested stream of %d events in %d batches", summary.Ingested, summary.Batches)
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+
+		event := e.ToModel()
+		if err := s.app.normalizeEvent(&event, time.Now().UTC()); err != nil {
+			return status.Errorf(codes.InvalidArgument, "event %d: %v", received, err)
+		}
+		received++
+
+		batch = append(batch, event)
+		if len(batch) == flushAt {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
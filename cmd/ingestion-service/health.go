@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/database"
+)
+
+// healthPingTimeout bounds each background ping
+const healthPingTimeout = 5 * time.Second
+
+// watchDatabase pings Postgres every interval until ctx is cancelled. After
+// a failed ping it drops the pool's connections, which may all be dead, and
+// once a ping succeeds again it re-runs the migrations so a database that
+// came back empty gets its schema before the next ingest hits it.
+func (app *App) watchDatabase(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	down := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+		err := app.db.Ping(pingCtx)
+		cancel()
+
+		if err != nil {
+			if !down {
+				log.Printf("Lost connection to Postgres: %v", err)
+				app.db.Reset()
+				down = true
+			}
+			continue
+		}
+		if !down {
+			continue
+		}
+
+		if err := database.Migrate(ctx, app.db); err != nil {
+			log.Printf("Postgres is reachable again but schema init failed, will retry: %v", err)
+			continue
+		}
+		log.Println("Reconnected to Postgres and re-checked the schema.")
+		down = false
+	}
+}
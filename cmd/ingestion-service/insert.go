@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/database"
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+const insertEventSQL = "INSERT INTO events (timestamp, level, severity, source, message) VALUES ($1, $2, $3, $4, $5)"
+
+// commitRetryBackoff is the wait before the first INGEST_COMMIT_RETRIES retry
+const commitRetryBackoff = 100 * time.Millisecond
+
+// maxReportedRowErrors caps the per-row errors returned to the client; the
+// failed count still covers every rejected row.
+const maxReportedRowErrors = 100
+
+// rowError identifies a rejected event by its index in the request batch.
+type rowError struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// chunkResult summarises a chunked insert.
+type chunkResult struct {
+	Inserted    int64
+	FailedCount int
+	Errors      []rowError
+
+	// failed holds every rejected index in order, including those past
+	// maxReportedRowErrors
+	failed []int
+}
+
+// acquireCopy reserves one of the POSTGRES_MAX_CONCURRENT_COPY write slots
+// without waiting, reporting false when they are all taken. Callers that
+// get true must call releaseCopy when their write is done.
+func (app *App) acquireCopy() bool {
+	if app.copySem == nil {
+		return true
+	}
+	select {
+	case app.copySem <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (app *App) releaseCopy() {
+	if app.copySem != nil {
+		<-app.copySem
+	}
+}
+
+// eventColumns are the events columns written on ingest, in row order.
+var eventColumns = []string{"timestamp", "level", "severity", "source", "message"}
+
+// insertEvents bulk-loads events in POSTGRES_COPY_CHUNK-row CopyFrom
+// calls inside one transaction and returns the row count. Rows are built
+// per chunk, so a huge batch never becomes one huge [][]any. Both the HTTP
+// and gRPC ingest paths write through it.
+//
+// A transient failure rolls the transaction back, so the whole batch is
+// retried up to INGEST_COMMIT_RETRIES times with doubling backoff. A commit
+// that fails with the connection lost may still have landed, so retries
+// can duplicate a batch in that rare case.
+func (app *App) insertEvents(ctx context.Context, events []models.Event) (int64, error) {
+	backoff := commitRetryBackoff
+	for attempt := 0; ; attempt++ {
+		n, err := database.BulkInsert(ctx, app.db, "events", eventColumns, len(events), app.cfg.CopyChunk,
+			func(i int) ([]any, error) {
+				e := events[i]
+				return []any{e.Timestamp, e.Level, models.Severity(e.Level), e.Source, e.Message}, nil
+			})
+		if err == nil || attempt == app.cfg.CommitRetries || !database.IsTransient(err) {
+			return n, err
+		}
+
+		log.Printf("Transient insert failure (attempt %d of %d), retrying in %s: %v",
+			attempt+1, app.cfg.CommitRetries+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			return 0, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// insertChunked loads events in CopyFrom chunks of size rows. When a chunk
+// fails, its rows are retried one INSERT at a time to find the offending
+// ones. An error is only returned when the failure is transient (see
+// database.IsTransient) or ctx is done.
+func (app *App) insertChunked(ctx context.Context, events []models.Event, size int) (chunkResult, error) {
+	res := chunkResult{Errors: []rowError{}}
+
+	for start := 0; start < len(events); start += size {
+		end := min(start+size, len(events))
+
+		n, err := app.insertEvents(ctx, events[start:end])
+		if err == nil {
+			res.Inserted += n
+			continue
+		}
+		if database.IsTransient(err) || ctx.Err() != nil {
+			return res, err
+		}
+
+		// The chunk was rejected as a whole; narrow it down row by row
+		for i := start; i < end; i++ {
+			e := events[i]
+			_, err := app.db.Exec(ctx, insertEventSQL, e.Timestamp, e.Level, models.Severity(e.Level), e.Source, e.Message)
+			if err == nil {
+				res.Inserted++
+				continue
+			}
+			if database.IsTransient(err) || ctx.Err() != nil {
+				return res, err
+			}
+			res.FailedCount++
+			res.failed = append(res.failed, i)
+			if len(res.Errors) < maxReportedRowErrors {
+				res.Errors = append(res.Errors, rowError{Index: i, ID: e.ID, Error: err.Error()})
+			}
+		}
+	}
+	return res, nil
+}
@@ -3,46 +3,148 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"time"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 
 	// Update this to your go.mod module name
 	"github.com/rajindersingh041/go-microservices/internal/database"
+	"github.com/rajindersingh041/go-microservices/internal/eventspb"
+	"github.com/rajindersingh041/go-microservices/internal/metrics"
 	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/openapi"
+	"github.com/rajindersingh041/go-microservices/internal/wait"
 )
 
 // App holds the concurrent-safe connection pool
 type App struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	cfg   Config
+	stats ingestStats
+
+	// copySem bounds concurrent batch writes; nil means unbounded
+	copySem chan struct{}
+
+	// limiter enforces INGEST_SOURCE_RATE; nil when it is off
+	limiter *sourceLimiter
 }
 
 func main() {
-	// ... (main function is unchanged)
+	if err := run(); err != nil {
+		log.Printf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the service and blocks until the server stops. Errors are
+// returned rather than fatal so the deferred pool Close always runs.
+func run() error {
+	flag.Parse()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Block until Postgres is listening rather than burn the connect retries
+	if cfg.WaitTimeout > 0 {
+		addr := database.Addr(database.PrimaryHost())
+		log.Printf("Waiting up to %s for Postgres at %s...", cfg.WaitTimeout, addr)
+		if err := wait.WaitForTCP(addr, cfg.WaitTimeout); err != nil {
+			return fmt.Errorf("postgres not reachable: %w", err)
+		}
+	}
+
 	conn, err := database.Connect()
 	if err != nil {
-		log.Fatalf("Failed to connect to Postgres: %v", err)
+		return fmt.Errorf("failed to connect to Postgres: %w", err)
 	}
 	defer conn.Close()
 
+	// Apply any pending schema migrations
+	if err := database.Migrate(context.Background(), conn); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	log.Println("Successfully connected to Postgres pool and schema is ready.")
 
-	app := &App{db: conn}
+	app := &App{db: conn, cfg: cfg}
+	if cfg.MaxConcurrentCopy > 0 {
+		app.copySem = make(chan struct{}, cfg.MaxConcurrentCopy)
+	}
+	if cfg.SourceRate > 0 {
+		app.limiter = newSourceLimiter(float64(cfg.SourceRate), cfg.SourceBurst)
+	}
+
+	if *selfTestEvents > 0 {
+		return app.selfTest(*selfTestEvents, *selfTestBatch)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.HealthInterval > 0 {
+		go app.watchDatabase(ctx, cfg.HealthInterval)
+	}
 
 	http.HandleFunc("/ingest", app.handleIngest)
+	http.HandleFunc("/stats", app.handleStats)
+	http.HandleFunc("/openapi.json", openapi.Handler)
+
+	if cfg.AdminEnabled {
+		http.HandleFunc("/admin/events", app.requireAdmin(app.handleDeleteEvents))
+		http.HandleFunc("/admin/init-schema", app.requireAdmin(app.handleInitSchema))
+		log.Println("Admin endpoints enabled.")
+	}
+
+	// Pool saturation gauges, read from pool.Stat() on every scrape
+	prometheus.MustRegister(metrics.NewPoolCollector(conn))
+	http.Handle("/metrics", promhttp.Handler())
+
+	// gRPC streaming ingest runs on its own port next to HTTP
+	lis, err := net.Listen("tcp", grpcPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+	grpcSrv := grpc.NewServer()
+	eventspb.RegisterEventIngestServer(grpcSrv, &grpcServer{app: app})
+	go func() {
+		log.Printf("Starting gRPC ingest on port %s...", grpcPort)
+		if err := grpcSrv.Serve(lis); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	defer grpcSrv.Stop()
 
 	port := ":8080"
 	log.Printf("Starting ingestion service on port %s...", port)
 	if err := http.ListenAndServe(port, nil); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		return fmt.Errorf("failed to start server: %w", err)
 	}
+	return nil
 }
 
-// handleIngest is now "smart" and handles both single and batch events
+// handleIngest counts every failed request for /stats around ingest.
 func (app *App) handleIngest(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w}
+	app.ingest(rec, r)
+	if rec.status >= http.StatusBadRequest {
+		app.stats.errors.Add(1)
+	}
+}
+
+// ingest is now "smart" and handles both single and batch events
+func (app *App) ingest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
@@ -56,64 +158,160 @@ func (app *App) handleIngest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 2. Decode: Protobuf or form fields when the producer says so, JSON otherwise
 	var events []models.Event
-
-	// --- THIS IS THE NEW "SMART" LOGIC ---
-	// 2. Try to unmarshal as an array (batch) first
-	err = json.Unmarshal(body, &events)
-	if err != nil {
-		// 3. If it's not an array, try to unmarshal as a single object
-		var singleEvent models.Event
-		err2 := json.Unmarshal(body, &singleEvent)
-		if err2 != nil {
-			// 4. If it's neither, the JSON is truly invalid
-			log.Printf("Failed to decode JSON as array or object: %v", err)
-			http.Error(w, "Failed to decode JSON: must be a single event object or an array of events", http.StatusBadRequest)
+	if isForm(r, body) {
+		events, err = decodeFormEvent(r, body, time.Now().UTC())
+		if err != nil {
+			http.Error(w, "Invalid form event: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if isProtobuf(r) {
+		events, err = decodeProtoEvents(body)
+		if err != nil {
+			log.Printf("Failed to decode Protobuf ingest body: %v", err)
+			http.Error(w, "Failed to decode Protobuf EventBatch: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		events, err = decodeEvents(body)
+		if err != nil {
+			log.Printf("Failed to decode ingest body: %v", err)
+			http.Error(w, "Failed to decode JSON: "+err.Error(), http.StatusBadRequest)
 			return
 		}
-		
-		// 5. It was a single object. Put it in the slice.
-		events = []models.Event{singleEvent}
 	}
-	// --- END OF NEW LOGIC ---
 
 	if len(events) == 0 {
 		http.Error(w, "Received empty event batch", http.StatusBadRequest)
 		return
 	}
 
-	// 6. The rest of the high-performance logic is UNCHANGED
-	// It works perfectly with a slice of 1 or 1,000,000
-	rows := make([][]interface{}, len(events))
-	for i, e := range events {
-		rows[i] = []interface{}{
-			e.Timestamp,
-			e.Level,
-			e.Source,
-			e.Message,
+	// 3. Fill defaults and enforce per-event rules
+	if err := app.normalizeEvents(events); err != nil {
+		http.Error(w, "Invalid event batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	ackIDs := wantsIDs(r, events)
+	if ackIDs {
+		assignIDs(events)
+	}
+
+	// 4. Charge each source for its events; the batch is all-or-nothing
+	refund := func() {}
+	if app.limiter != nil {
+		if refund, err = app.limiter.allow(events); err != nil {
+			var te *throttledError
+			errors.As(err, &te)
+			w.Header().Set("X-Throttled-Source", te.Source)
+			if te.RetryAfter == 0 {
+				// More events for one source than the burst; retrying can't help
+				http.Error(w, "Batch too large: "+err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			w.Header().Set("Retry-After", te.retryAfterSeconds())
+			http.Error(w, "Rate limit exceeded: "+err.Error(), http.StatusTooManyRequests)
+			return
 		}
 	}
 
-	tableName := pgx.Identifier{"events"}
-	colNames := []string{"timestamp", "level", "source", "message"}
+	// 5. Shed load instead of queueing when enough big writes are running,
+	// each holding a pool connection and its batch in memory
+	if !app.acquireCopy() {
+		refund() // Shed before any write, so don't charge the sources
+		w.Header().Set("Retry-After", database.RetryAfterSeconds)
+		http.Error(w, "Too many concurrent ingests, retry later", http.StatusServiceUnavailable)
+		return
+	}
+	defer app.releaseCopy()
+
+	// 6. The rest of the high-performance logic is UNCHANGED
+	// It works perfectly with a slice of 1 or 1,000,000
+	// The write is bounded so a stalled database can't pin the request
+	// (and its connection) forever; a client disconnect cancels it too
+	ctx, cancel := context.WithTimeout(r.Context(), app.cfg.WriteTimeout)
+	defer cancel()
 
-	copyCount, err := app.db.CopyFrom(
-		context.Background(),
-		tableName,
-		colNames,
-		pgx.CopyFromRows(rows),
-	)
+	if app.cfg.ChunkSize > 0 {
+		app.ingestChunked(ctx, w, events, ackIDs)
+		return
+	}
 
+	copyCount, err := app.insertEvents(ctx, events)
 	if err != nil {
 		log.Printf("Error during batch insert: %v", err)
-		http.Error(w, "Server error during batch insert", http.StatusInternalServerError)
+		writeError(ctx, w, err, "Server error during batch insert")
 		return
 	}
 
+	app.stats.record(copyCount)
 	log.Printf("Successfully ingested batch of %d events", copyCount)
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"status":   "accepted",
 		"ingested": copyCount,
-	})
-}
\ No newline at end of file
+	}
+	if ackIDs {
+		resp["accepted"] = acceptedIDs(events, nil)
+	}
+	w.WriteHeader(app.cfg.SuccessStatus)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ingestChunked writes events chunk by chunk and reports which rows, if any,
+// were rejected. Good chunks stay committed even when a later one fails.
+// With ackIDs the response also lists the IDs of the events that landed.
+func (app *App) ingestChunked(ctx context.Context, w http.ResponseWriter, events []models.Event, ackIDs bool) {
+	res, err := app.insertChunked(ctx, events, app.cfg.ChunkSize)
+	if err != nil {
+		log.Printf("Error during chunked insert after %d events: %v", res.Inserted, err)
+		writeError(ctx, w, err, "Server error during batch insert")
+		return
+	}
+
+	status, code := "accepted", app.cfg.SuccessStatus
+	switch {
+	case res.FailedCount > 0 && res.Inserted == 0:
+		status, code = "rejected", http.StatusUnprocessableEntity
+	case res.FailedCount > 0:
+		status, code = "partial", http.StatusMultiStatus
+	}
+
+	if res.Inserted > 0 {
+		app.stats.record(res.Inserted)
+	}
+	log.Printf("Chunked ingest: %d inserted, %d failed", res.Inserted, res.FailedCount)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	resp := map[string]interface{}{
+		"status":   status,
+		"ingested": res.Inserted,
+		"failed":   res.FailedCount,
+		"errors":   res.Errors,
+	}
+	if ackIDs {
+		resp["accepted"] = acceptedIDs(events, res.failed)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeError answers a failed write: 504 when ctx hit its write deadline,
+// otherwise whatever dbError decides.
+func writeError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		http.Error(w, "Timed out writing to the database", http.StatusGatewayTimeout)
+		return
+	}
+	dbError(w, err, msg)
+}
+
+// dbError answers a failed database call: 503 with Retry-After when Postgres
+// is unreachable or the failure was otherwise transient, e.g. retries ran
+// out on a serialization conflict, and a 500 carrying msg for anything else.
+func dbError(w http.ResponseWriter, err error, msg string) {
+	if database.IsTransient(err) {
+		w.Header().Set("Retry-After", database.RetryAfterSeconds)
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
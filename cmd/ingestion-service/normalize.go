@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+// Timestamp policies for events that arrive without a timestamp
+const (
+	timestampNow    = "now"
+	timestampReject = "reject"
+)
+
+var (
+	errMissingTimestamp = errors.New("missing timestamp")
+	errMissingLevel     = errors.New("missing level")
+)
+
+// invalidEventError identifies the event in a batch that failed validation.
+type invalidEventError struct {
+	Index int
+	Err   error
+}
+
+func (e *invalidEventError) Error() string {
+	return fmt.Sprintf("event %d: %v", e.Index, e.Err)
+}
+
+func (e *invalidEventError) Unwrap() error { return e.Err }
+
+// normalizeEvents fills defaults on every event in place and stops at the
+// first one that is rejected, returning an *invalidEventError.
+func (app *App) normalizeEvents(events []models.Event) error {
+	now := time.Now().UTC()
+	for i := range events {
+		if err := app.normalizeEvent(&events[i], now); err != nil {
+			return &invalidEventError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// normalizeEvent applies the configured defaults to a single event.
+func (app *App) normalizeEvent(e *models.Event, now time.Time) error {
+	// A zero timestamp would be stored as year 1 and sink to the bottom of
+	// every time-ordered query
+	if e.Timestamp.IsZero() {
+		if app.cfg.TimestampPolicy == timestampReject {
+			return errMissingTimestamp
+		}
+		e.Timestamp = now
+	}
+
+	// Stored timestamps are UTC whatever zone the client sent
+	e.Timestamp = e.Timestamp.UTC()
+
+	// An empty level would never match ?level= or ?min_severity=
+	if strings.TrimSpace(e.Level) == "" {
+		if app.cfg.RequireLevel {
+			return errMissingLevel
+		}
+		e.Level = app.cfg.DefaultLevel
+	}
+
+	// Matching is case-insensitive and rewrites the source to its configured
+	// spelling, so "Payment-Svc" and "payment-svc" land as one source
+	if app.cfg.AllowedSources != nil {
+		canonical, ok := app.cfg.AllowedSources[strings.ToLower(e.Source)]
+		if !ok {
+			return fmt.Errorf("source %q is not in INGEST_ALLOWED_SOURCES", e.Source)
+		}
+		e.Source = canonical
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+// sweepInterval is how often idle source buckets are looked for
+const sweepInterval = time.Minute
+
+// sourceLimiter is a token bucket per event source, charged one token per
+// event, so one chatty service can't crowd out the rest of a shared
+// pipeline. Buckets are created on first use. Sources are client-supplied,
+// so a bucket that has refilled to its burst, and so is no different from
+// a new one, is dropped by the next sweep.
+type sourceLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	sources   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+func newSourceLimiter(perSecond float64, burst int) *sourceLimiter {
+	return &sourceLimiter{
+		limit:     rate.Limit(perSecond),
+		burst:     burst,
+		sources:   make(map[string]*rate.Limiter),
+		lastSweep: time.Now(),
+	}
+}
+
+// throttledError names the source that ran out of budget.
+type throttledError struct {
+	Source     string
+	RetryAfter time.Duration // 0 when the batch can never fit the burst
+}
+
+func (e *throttledError) Error() string {
+	if e.RetryAfter == 0 {
+		return fmt.Sprintf("source %q sent more events in one batch than INGEST_SOURCE_BURST allows", e.Source)
+	}
+	return fmt.Sprintf("source %q exceeded INGEST_SOURCE_RATE", e.Source)
+}
+
+// retryAfterSeconds rounds RetryAfter up for a Retry-After header.
+func (e *throttledError) retryAfterSeconds() string {
+	return fmt.Sprint(max(1, int(math.Ceil(e.RetryAfter.Seconds()))))
+}
+
+func (l *sourceLimiter) get(source string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+	lim, ok := l.sources[source]
+	if !ok {
+		lim = rate.NewLimiter(l.limit, l.burst)
+		l.sources[source] = lim
+	}
+	return lim
+}
+
+// sweep drops every bucket that is full again. l.mu must be held.
+func (l *sourceLimiter) sweep(now time.Time) {
+	for source, lim := range l.sources {
+		if lim.TokensAt(now) >= float64(l.burst) {
+			delete(l.sources, source)
+		}
+	}
+	l.lastSweep = now
+}
+
+// allow charges every source in events for its share of the batch. The
+// batch is all-or-nothing: if any source is over budget nothing is charged
+// and a *throttledError for that source is returned. Otherwise refund
+// hands the tokens back, for a batch that is then not written after all.
+func (l *sourceLimiter) allow(events []models.Event) (refund func(), err error) {
+	perSource := make(map[string]int)
+	for _, e := range events {
+		perSource[e.Source]++
+	}
+
+	now := time.Now()
+	var taken []*rate.Reservation
+	for source, n := range perSource {
+		r := l.get(source, now).ReserveN(now, n)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			err := &throttledError{Source: source}
+			if r.OK() {
+				err.RetryAfter = r.DelayFrom(now)
+				r.CancelAt(now)
+			}
+			for _, t := range taken {
+				t.CancelAt(now)
+			}
+			return nil, err
+		}
+		taken = append(taken, r)
+	}
+	// Cancelled as of now: rate ignores a cancel after a reservation's
+	// time to act, which for an immediate one is already past
+	return func() {
+		for _, t := range taken {
+			t.CancelAt(now)
+		}
+	}, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ingestStats are lifetime counters for GET /stats. They live in-process so
+// operators get an at-a-glance view with curl, no Prometheus needed.
+type ingestStats struct {
+	events     atomic.Int64
+	batches    atomic.Int64
+	errors     atomic.Int64
+	lastIngest atomic.Int64 // Unix nanoseconds; 0 until the first batch lands
+}
+
+// record counts one successfully written batch of n events.
+func (s *ingestStats) record(n int64) {
+	s.events.Add(n)
+	s.batches.Add(1)
+	s.lastIngest.Store(time.Now().UnixNano())
+}
+
+func (app *App) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var last interface{} // null until something has been ingested
+	if ns := app.stats.lastIngest.Load(); ns != 0 {
+		last = time.Unix(0, ns).UTC().Format(time.RFC3339Nano)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_events_ingested": app.stats.events.Load(),
+		"total_batches":         app.stats.batches.Load(),
+		"last_ingest_time":      last,
+		"errors":                app.stats.errors.Load(),
+	})
+}
+
+// statusRecorder remembers the status code a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -11,6 +12,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/httpx"
 )
 
 // This must match the model in your main project
@@ -35,12 +38,18 @@ var (
 	ingestURL        = flag.String("ingest-url", "http://localhost:8080/ingest", "Ingestion service URL")
 	queryURL         = flag.String("query-url", "http://localhost:8081/query", "Query service URL")
 
-	// HTTP client with timeout
-	client = &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	// Concurrency caps; 0 for ingest/query falls back to -max-concurrency
+	maxConcurrency       = flag.Int("max-concurrency", 100, "Max in-flight requests per worker kind (0 = unbounded)")
+	maxIngestConcurrency = flag.Int("max-ingest-concurrency", 0, "Max in-flight ingest requests (default -max-concurrency)")
+	maxQueryConcurrency  = flag.Int("max-query-concurrency", 0, "Max in-flight query requests (default -max-concurrency)")
+
+	// Warmup traffic is real (ingested events are stored) but not reported
+	warmup = flag.Duration("warmup", 0, "Send unrecorded traffic for this long before measuring, e.g. 5s")
 
-	levels = []string{"INFO", "WARN", "ERROR", "DEBUG"}
+	// HTTP client with timeout. No retries: every attempt must be counted
+	client = httpx.NewClient(httpx.Options{Timeout: 30 * time.Second})
+
+	levels  = []string{"INFO", "WARN", "ERROR", "DEBUG"}
 	sources = []string{"payment-svc", "auth-svc", "cart-svc", "frontend"}
 )
 
@@ -88,8 +97,10 @@ func runIngestWorker(wg *sync.WaitGroup) {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusAccepted {
-		log.Printf("Ingest request got non-202 status: %s", resp.Status)
+	// The success status is INGEST_SUCCESS_STATUS, so take any 2xx; a 207
+	// means part of the batch was rejected
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || resp.StatusCode == http.StatusMultiStatus {
+		log.Printf("Ingest request got non-2xx or partial status: %s", resp.Status)
 		ingestFailure.Add(uint64(*eventsPerBatch))
 		return
 	}
@@ -120,32 +131,110 @@ func runQueryWorker(wg *sync.WaitGroup) {
 	querySuccess.Add(1)
 }
 
+// concurrencyLimit resolves a per-kind cap against -max-concurrency.
+func concurrencyLimit(perKind int) int {
+	if perKind > 0 {
+		return perKind
+	}
+	return *maxConcurrency
+}
+
+// spawnBounded runs worker n times with at most limit calls in flight,
+// using a fixed pool of goroutines fed from a jobs channel. A limit of 0
+// starts all n at once, the tester's original behaviour.
+func spawnBounded(wg *sync.WaitGroup, n, limit int, worker func(*sync.WaitGroup)) {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+
+	wg.Add(n)
+	jobs := make(chan struct{})
+	go func() {
+		for i := 0; i < n; i++ {
+			jobs <- struct{}{}
+		}
+		close(jobs)
+	}()
+
+	for i := 0; i < limit; i++ {
+		go func() {
+			for range jobs {
+				worker(wg)
+			}
+		}()
+	}
+}
+
+// runWarmup keeps limit workers of each kind busy until d has passed, then
+// zeroes the counters so connection setup doesn't skew the measured run.
+func runWarmup(d time.Duration, queryLimit, ingestLimit int) {
+	deadline := time.Now().Add(d)
+	var wg sync.WaitGroup
+
+	loop := func(limit int, worker func(*sync.WaitGroup)) {
+		for i := 0; i < limit; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for time.Now().Before(deadline) {
+					var one sync.WaitGroup
+					one.Add(1)
+					worker(&one)
+				}
+			}()
+		}
+	}
+	loop(queryLimit, runQueryWorker)
+	loop(ingestLimit, runIngestWorker)
+	wg.Wait()
+
+	ingestSuccess.Store(0)
+	ingestFailure.Store(0)
+	querySuccess.Store(0)
+	queryFailure.Store(0)
+}
+
 func main() {
 	flag.Parse()
 
+	if *targetsFile != "" {
+		runTargetMode()
+		return
+	}
+
 	totalEvents := *numIngestBatches * *eventsPerBatch
 	log.Printf("--- Starting Load Test ---")
 	log.Printf("Query Service: %d concurrent requests", *numQueries)
 	log.Printf("Ingest Service: %d batches @ %d events/batch (Total: %d events)", *numIngestBatches, *eventsPerBatch, totalEvents)
+	log.Printf("Concurrency: %d query / %d ingest in flight (0 = unbounded)", concurrencyLimit(*maxQueryConcurrency), concurrencyLimit(*maxIngestConcurrency))
 	log.Printf("----------------------------")
 
-	startTime := time.Now()
-	var wg sync.WaitGroup
+	// --- Size the worker pools ---
+	queryLimit := concurrencyLimit(*maxQueryConcurrency)
+	ingestLimit := concurrencyLimit(*maxIngestConcurrency)
 
-	// --- Spawn all goroutines ---
-
-	// Spawn query workers
-	wg.Add(*numQueries)
-	for i := 0; i < *numQueries; i++ {
-		go runQueryWorker(&wg)
+	// Keep enough idle connections around that capped workers reuse them
+	// instead of re-dialing on every request
+	if queryLimit > 0 && ingestLimit > 0 {
+		client = httpx.NewClient(httpx.Options{
+			Timeout:             30 * time.Second,
+			MaxIdleConns:        queryLimit + ingestLimit,
+			MaxIdleConnsPerHost: max(queryLimit, ingestLimit),
+		})
 	}
 
-	// Spawn ingest workers
-	wg.Add(*numIngestBatches)
-	for i := 0; i < *numIngestBatches; i++ {
-		go runIngestWorker(&wg)
+	if *warmup > 0 {
+		log.Printf("Warming up for %s (not recorded)...", *warmup)
+		runWarmup(*warmup, cmp.Or(queryLimit, *numQueries), cmp.Or(ingestLimit, *numIngestBatches))
 	}
 
+	startTime := time.Now()
+	var wg sync.WaitGroup
+
+	// --- Spawn bounded worker pools ---
+	spawnBounded(&wg, *numQueries, queryLimit, runQueryWorker)
+	spawnBounded(&wg, *numIngestBatches, ingestLimit, runIngestWorker)
+
 	// --- Wait for all to finish ---
 	log.Println("All workers spawned, waiting for completion...")
 	wg.Wait()
@@ -164,4 +253,4 @@ func main() {
 	log.Printf("  Success: %d events", ingestSuccess.Load())
 	log.Printf("  Failure: %d events", ingestFailure.Load())
 	log.Printf("  Rate:    %.2f events/s", float64(ingestSuccess.Load())/duration.Seconds())
-}
\ No newline at end of file
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/httpx"
+)
+
+var (
+	targetsFile   = flag.String("targets", "", "JSON file listing targets to load-test together (replaces -ingest-url/-query-url mode)")
+	totalRequests = flag.Int("requests", 1000, "Total requests spread across -targets by weight")
+)
+
+// target is one endpoint in a -targets run, e.g.
+//
+//	{"name": "ingest", "method": "POST", "url": "http://localhost:8080/ingest",
+//	 "payload": "{{events 100}}", "weight": 1}
+//
+// Payload is a text/template rendered per request; see payloadFuncs.
+type target struct {
+	Name        string `json:"name"`
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Payload     string `json:"payload"`
+	ContentType string `json:"content_type"`
+	Weight      int    `json:"weight"`
+
+	tmpl  *template.Template
+	stats targetStats
+}
+
+// targetStats are the per-target results of a run.
+type targetStats struct {
+	success atomic.Uint64
+	failure atomic.Uint64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+func (s *targetStats) reset() {
+	s.success.Store(0)
+	s.failure.Store(0)
+	s.mu.Lock()
+	s.latencies = nil
+	s.mu.Unlock()
+}
+
+// payloadFuncs are available inside a target's payload template.
+var payloadFuncs = template.FuncMap{
+	// events n renders a JSON array of n random events
+	"events": func(n int) (string, error) {
+		b, err := json.Marshal(generateBatch(n))
+		return string(b), err
+	},
+	// event renders a single random event object
+	"event": func() (string, error) {
+		b, err := json.Marshal(generateBatch(1)[0])
+		return string(b), err
+	},
+	"now":     func() string { return time.Now().UTC().Format(time.RFC3339Nano) },
+	"randInt": func(n int) int { return rand.Intn(n) },
+	"level":   func() string { return levels[rand.Intn(len(levels))] },
+	"source":  func() string { return sources[rand.Intn(len(sources))] },
+}
+
+// loadTargets reads and validates the -targets file.
+func loadTargets(path string) ([]*target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var targets []*target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("invalid targets file: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("targets file %s lists no targets", path)
+	}
+
+	for i, t := range targets {
+		if t.URL == "" {
+			return nil, fmt.Errorf("target %d has no url", i)
+		}
+		if t.Name == "" {
+			t.Name = t.URL
+		}
+		if t.Method == "" {
+			t.Method = http.MethodGet
+		}
+		t.Method = strings.ToUpper(t.Method)
+		if t.Weight <= 0 {
+			t.Weight = 1
+		}
+		if t.ContentType == "" && t.Payload != "" {
+			t.ContentType = "application/json"
+		}
+		if t.tmpl, err = template.New(t.Name).Funcs(payloadFuncs).Parse(t.Payload); err != nil {
+			return nil, fmt.Errorf("target %s: invalid payload template: %w", t.Name, err)
+		}
+	}
+	return targets, nil
+}
+
+// pickTarget chooses a target at random in proportion to its weight.
+func pickTarget(targets []*target, totalWeight int) *target {
+	n := rand.Intn(totalWeight)
+	for _, t := range targets {
+		if n < t.Weight {
+			return t
+		}
+		n -= t.Weight
+	}
+	return targets[len(targets)-1]
+}
+
+// hit sends one request to t and records the outcome and latency.
+func (t *target) hit() {
+	var body io.Reader
+	if t.Payload != "" {
+		var buf bytes.Buffer
+		if err := t.tmpl.Execute(&buf, nil); err != nil {
+			log.Printf("[%s] Failed to render payload: %v", t.Name, err)
+			t.stats.failure.Add(1)
+			return
+		}
+		body = &buf
+	}
+
+	req, err := http.NewRequest(t.Method, t.URL, body)
+	if err != nil {
+		log.Printf("[%s] Failed to create request: %v", t.Name, err)
+		t.stats.failure.Add(1)
+		return
+	}
+	if t.ContentType != "" {
+		req.Header.Set("Content-Type", t.ContentType)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("[%s] Request failed: %v", t.Name, err)
+		t.stats.failure.Add(1)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	t.stats.mu.Lock()
+	t.stats.latencies = append(t.stats.latencies, elapsed)
+	t.stats.mu.Unlock()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Printf("[%s] Got non-2xx status: %s", t.Name, resp.Status)
+		t.stats.failure.Add(1)
+		return
+	}
+	t.stats.success.Add(1)
+}
+
+// runTargetMode load-tests every target in -targets in one run.
+func runTargetMode() {
+	targets, err := loadTargets(*targetsFile)
+	if err != nil {
+		log.Fatalf("Failed to load targets: %v", err)
+	}
+	totalWeight := 0
+	for _, t := range targets {
+		totalWeight += t.Weight
+	}
+
+	limit := *maxConcurrency
+	if limit <= 0 || limit > *totalRequests {
+		limit = *totalRequests
+	}
+	client = httpx.NewClient(httpx.Options{
+		Timeout:             30 * time.Second,
+		MaxIdleConns:        limit,
+		MaxIdleConnsPerHost: limit,
+	})
+
+	log.Printf("--- Starting Load Test ---")
+	for _, t := range targets {
+		log.Printf("Target %s: %s %s (weight %d/%d)", t.Name, t.Method, t.URL, t.Weight, totalWeight)
+	}
+	log.Printf("Requests: %d with %d in flight", *totalRequests, limit)
+	log.Printf("----------------------------")
+
+	worker := func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		pickTarget(targets, totalWeight).hit()
+	}
+
+	if *warmup > 0 {
+		log.Printf("Warming up for %s (not recorded)...", *warmup)
+		deadline := time.Now().Add(*warmup)
+		var wg sync.WaitGroup
+		for i := 0; i < limit; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for time.Now().Before(deadline) {
+					pickTarget(targets, totalWeight).hit()
+				}
+			}()
+		}
+		wg.Wait()
+		for _, t := range targets {
+			t.stats.reset()
+		}
+	}
+
+	startTime := time.Now()
+	var wg sync.WaitGroup
+	spawnBounded(&wg, *totalRequests, limit, worker)
+	log.Println("All workers spawned, waiting for completion...")
+	wg.Wait()
+	duration := time.Since(startTime)
+
+	log.Printf("--- Test Complete ---")
+	log.Printf("Duration: %s", duration)
+	for _, t := range targets {
+		t.report(duration)
+	}
+}
+
+// report prints the target's counts, rate and latency percentiles.
+func (t *target) report(duration time.Duration) {
+	t.stats.mu.Lock()
+	lat := slices.Clone(t.stats.latencies)
+	t.stats.mu.Unlock()
+	slices.Sort(lat)
+
+	pct := func(p float64) time.Duration {
+		if len(lat) == 0 {
+			return 0
+		}
+		return lat[int(p*float64(len(lat)-1))]
+	}
+
+	log.Println("---")
+	log.Printf("%s (%s %s):", t.Name, t.Method, t.URL)
+	log.Printf("  Success: %d", t.stats.success.Load())
+	log.Printf("  Failure: %d", t.stats.failure.Load())
+	log.Printf("  Rate:    %.2f req/s", float64(t.stats.success.Load())/duration.Seconds())
+	if len(lat) > 0 {
+		log.Printf("  Latency: p50 %s  p95 %s  p99 %s  max %s", pct(0.50), pct(0.95), pct(0.99), lat[len(lat)-1])
+	}
+}
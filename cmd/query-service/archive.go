@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+const day = 24 * time.Hour
+
+// handleArchive streams every event in [from, to] as a zip with one NDJSON
+// entry per UTC day, events_YYYY-MM-DD.ndjson, oldest first. Every day in
+// the range gets an entry, empty or not, so a missing day in cold storage
+// is never ambiguous. The span is capped by QUERY_ARCHIVE_MAX_SPAN.
+func (app *App) handleArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, err := query.ParseParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.From.IsZero() || p.To.IsZero() {
+		http.Error(w, "Both from and to are required for an archive", http.StatusBadRequest)
+		return
+	}
+	if span := p.To.Sub(p.From); span > app.cfg.ArchiveMaxSpan {
+		http.Error(w, fmt.Sprintf("Archive range %s exceeds the maximum of %s", span, app.cfg.ArchiveMaxSpan), http.StatusBadRequest)
+		return
+	}
+
+	where := eventFilter(p)
+	stmt := "SELECT Timestamp, Level, Source, Message FROM events" + where.String() + " ORDER BY Timestamp ASC"
+
+	// Tied to the request so a client that gives up stops the scan
+	rows, err := app.db.Query(r.Context(), stmt, where.args...)
+	if err != nil {
+		log.Printf("Error executing archive query: %v", err)
+		dbError(w, err, "Server error")
+		return
+	}
+	defer rows.Close()
+
+	first, last := p.From.UTC().Truncate(day), p.To.UTC().Truncate(day)
+	filename := fmt.Sprintf("events_%s_%s.zip", first.Format(time.DateOnly), last.Format(time.DateOnly))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// From here on the status is sent, so failures can only be logged, and
+	// the client is left with a truncated zip it will fail to open
+	flusher, _ := w.(http.Flusher)
+	zw := zip.NewWriter(w)
+	var enc *json.Encoder
+	current := first.Add(-day)
+	nextDay := func() error {
+		current = current.Add(day)
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("events_%s.ndjson", current.Format(time.DateOnly)),
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		enc = json.NewEncoder(f)
+		return nil
+	}
+
+	n := 0
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.Timestamp, &e.Level, &e.Source, &e.Message); err != nil {
+			log.Printf("Error scanning archive row %d: %v", n, err)
+			return
+		}
+
+		for !current.Equal(e.Timestamp.UTC().Truncate(day)) {
+			if err := nextDay(); err != nil {
+				log.Printf("Archive aborted after %d rows: %v", n, err)
+				return
+			}
+		}
+		if err := enc.Encode(app.formatEvent(e)); err != nil {
+			log.Printf("Archive aborted after %d rows: %v", n, err)
+			return
+		}
+
+		n++
+		if n%exportFlushRows == 0 {
+			zw.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Archive aborted after %d rows: %v", n, err)
+		return
+	}
+	for current.Before(last) {
+		if err := nextDay(); err != nil {
+			log.Printf("Archive aborted after %d rows: %v", n, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("Archive aborted after %d rows: %v", n, err)
+		return
+	}
+	log.Printf("Archived %d events over %d days", n, int(last.Sub(first)/day)+1)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/compression"
+	"github.com/rajindersingh041/go-microservices/internal/config"
+	"github.com/rajindersingh041/go-microservices/internal/cors"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// Config holds the query-service settings read from the environment.
+type Config struct {
+	// LiveInterval (QUERY_LIVE_INTERVAL) is how often /query/levels/live
+	// pushes a fresh snapshot. Defaults to 5s.
+	LiveInterval time.Duration
+
+	// CORSOrigins (CORS_ALLOWED_ORIGINS) is a comma-separated list of
+	// browser origins, or "*". Empty, the default, disables CORS.
+	CORSOrigins []string
+
+	// CORSMaxAge (CORS_MAX_AGE) is how long browsers may cache a preflight
+	// result. Defaults to 600s.
+	CORSMaxAge time.Duration
+
+	// GzipMinBytes (GZIP_MIN_BYTES) is the smallest response that gets
+	// gzipped. Defaults to 1KB.
+	GzipMinBytes int
+
+	// GzipLevel (GZIP_LEVEL) is the compress/gzip level, -2 to 9.
+	// Defaults to gzip.DefaultCompression.
+	GzipLevel int
+
+	// ExportMaxSpan (QUERY_EXPORT_MAX_SPAN) caps the to-from range of a
+	// single /query/export. Defaults to 24h.
+	ExportMaxSpan time.Duration
+
+	// ArchiveMaxSpan (QUERY_ARCHIVE_MAX_SPAN) caps the to-from range of a
+	// single /query/archive. Defaults to 31 days (744h).
+	ArchiveMaxSpan time.Duration
+
+	// QueryTimeout (QUERY_TIMEOUT) bounds a single /query database call on
+	// top of the request's own context. Defaults to 5s.
+	QueryTimeout time.Duration
+
+	// WaitTimeout (POSTGRES_WAIT_TIMEOUT) is how long startup waits for the
+	// Postgres port to accept connections before connecting. 0, the
+	// default, connects straight away.
+	WaitTimeout time.Duration
+
+	// HardMaxRows (QUERY_HARD_MAX_ROWS) is the most rows any list endpoint
+	// returns, whatever limit was asked for. Defaults to query.MaxLimit.
+	HardMaxRows int
+
+	// DefaultWindow (QUERY_EVENTS_DEFAULT_WINDOW) scopes a /query with
+	// neither from nor to to the trailing window, e.g. 24h. 0, the default,
+	// leaves such queries unbounded.
+	DefaultWindow time.Duration
+
+	// TimeLayout (QUERY_TIME_FORMAT) is how timestamps are written in
+	// responses: RFC3339, RFC3339Milli, RFC3339Micro, RFC3339Nano or a Go
+	// layout. Defaults to RFC3339.
+	TimeLayout string
+}
+
+func loadConfig() (Config, error) {
+	var cfg Config
+	var err error
+
+	if cfg.LiveInterval, err = config.Duration("QUERY_LIVE_INTERVAL", 5*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.LiveInterval < time.Second {
+		return cfg, fmt.Errorf("QUERY_LIVE_INTERVAL must be at least 1s, got %s", cfg.LiveInterval)
+	}
+
+	cfg.CORSOrigins = cors.ParseOrigins(config.String("CORS_ALLOWED_ORIGINS", ""))
+	if cfg.CORSMaxAge, err = config.Duration("CORS_MAX_AGE", 600*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.CORSMaxAge < 0 {
+		return cfg, fmt.Errorf("CORS_MAX_AGE must not be negative, got %s", cfg.CORSMaxAge)
+	}
+
+	if cfg.GzipMinBytes, err = config.Int("GZIP_MIN_BYTES", 1024); err != nil {
+		return cfg, err
+	}
+	if cfg.GzipMinBytes < 0 {
+		return cfg, fmt.Errorf("GZIP_MIN_BYTES must not be negative, got %d", cfg.GzipMinBytes)
+	}
+	if cfg.GzipLevel, err = config.Int("GZIP_LEVEL", gzip.DefaultCompression); err != nil {
+		return cfg, err
+	}
+	if !compression.ValidLevel(cfg.GzipLevel) {
+		return cfg, fmt.Errorf("GZIP_LEVEL must be between %d and %d, got %d", gzip.HuffmanOnly, gzip.BestCompression, cfg.GzipLevel)
+	}
+
+	if cfg.ExportMaxSpan, err = config.Duration("QUERY_EXPORT_MAX_SPAN", 24*time.Hour); err != nil {
+		return cfg, err
+	}
+	if cfg.ExportMaxSpan <= 0 {
+		return cfg, fmt.Errorf("QUERY_EXPORT_MAX_SPAN must be positive, got %s", cfg.ExportMaxSpan)
+	}
+
+	if cfg.ArchiveMaxSpan, err = config.Duration("QUERY_ARCHIVE_MAX_SPAN", 31*24*time.Hour); err != nil {
+		return cfg, err
+	}
+	if cfg.ArchiveMaxSpan <= 0 {
+		return cfg, fmt.Errorf("QUERY_ARCHIVE_MAX_SPAN must be positive, got %s", cfg.ArchiveMaxSpan)
+	}
+
+	if cfg.QueryTimeout, err = config.Duration("QUERY_TIMEOUT", 5*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.QueryTimeout <= 0 {
+		return cfg, fmt.Errorf("QUERY_TIMEOUT must be positive, got %s", cfg.QueryTimeout)
+	}
+
+	if cfg.WaitTimeout, err = config.Duration("POSTGRES_WAIT_TIMEOUT", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.WaitTimeout < 0 {
+		return cfg, fmt.Errorf("POSTGRES_WAIT_TIMEOUT must not be negative, got %s", cfg.WaitTimeout)
+	}
+
+	if cfg.HardMaxRows, err = config.Int("QUERY_HARD_MAX_ROWS", query.MaxLimit); err != nil {
+		return cfg, err
+	}
+	if cfg.HardMaxRows < 1 {
+		return cfg, fmt.Errorf("QUERY_HARD_MAX_ROWS must be at least 1, got %d", cfg.HardMaxRows)
+	}
+
+	if cfg.DefaultWindow, err = config.Duration("QUERY_EVENTS_DEFAULT_WINDOW", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.DefaultWindow < 0 {
+		return cfg, fmt.Errorf("QUERY_EVENTS_DEFAULT_WINDOW must not be negative, got %s", cfg.DefaultWindow)
+	}
+
+	if cfg.TimeLayout, err = parseTimeFormat(config.String("QUERY_TIME_FORMAT", "RFC3339")); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// Limits on a /query/filter request so one body can't build a huge query.
+const (
+	maxFilterBody  = 64 << 10
+	maxFilterDepth = 8
+	maxFilterNodes = 100
+)
+
+// filterOps maps the DSL operators to SQL. "in" and "contains" are handled
+// separately.
+var filterOps = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+// filterNode is one node of the filter DSL: either a comparison
+// {"field", "op", "value"} or a group {"and": [...]} / {"or": [...]}.
+type filterNode struct {
+	Field string          `json:"field"`
+	Op    string          `json:"op"`
+	Value json.RawMessage `json:"value"`
+	And   []filterNode    `json:"and"`
+	Or    []filterNode    `json:"or"`
+}
+
+// filterRequest is the POST /query/filter body.
+type filterRequest struct {
+	Where  filterNode `json:"where"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+// handleFilter runs a search whose conditions are an AND/OR tree, e.g.
+// (level=ERROR) OR (source=payment-svc AND level=WARN), which the flat
+// query params can't express. Fields and operators are whitelisted and
+// every value is a bind parameter.
+func (app *App) handleFilter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	envelope, err := query.WantsEnvelope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 1. Decode the body
+	var req filterRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFilterBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "Failed to decode filter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = query.DefaultLimit
+	}
+	if req.Limit < 1 {
+		http.Error(w, "limit must be at least 1", http.StatusBadRequest)
+		return
+	}
+	if req.Offset < 0 {
+		http.Error(w, "offset must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	// 2. Compile the tree to a parameterized WHERE clause
+	where := &whereBuilder{}
+	nodes := 0
+	expr, err := where.compile(req.Where, 1, &nodes)
+	if err != nil {
+		http.Error(w, "Invalid filter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	where.conds = append(where.conds, expr)
+
+	filterSQL, filterArgs := where.String(), where.args
+	stmt := "SELECT Timestamp, Level, Source, Message FROM events" + filterSQL +
+		" ORDER BY Timestamp DESC LIMIT " + where.arg(app.capLimit(req.Limit)) + " OFFSET " + where.arg(req.Offset)
+
+	// 3. Run it
+	ctx, cancel := context.WithTimeout(r.Context(), app.cfg.QueryTimeout)
+	defer cancel()
+
+	rows, err := app.db.Query(ctx, stmt, where.args...)
+	if err != nil {
+		log.Printf("Error executing filter query: %v", err)
+		queryError(ctx, w, err, "Server error")
+		return
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Event])
+	if err != nil {
+		log.Printf("Error scanning rows: %v", err)
+		queryError(ctx, w, err, "Server error")
+		return
+	}
+
+	var body interface{} = app.formatEvents(events)
+	if envelope {
+		total, err := app.countEvents(ctx, filterSQL, filterArgs)
+		if err != nil {
+			log.Printf("Error counting filter matches: %v", err)
+			queryError(ctx, w, err, "Server error")
+			return
+		}
+		body = query.NewEnvelope(body, app.capLimit(req.Limit), req.Offset, len(events), total)
+	}
+
+	app.setRowCapHeaders(w, req.Limit, len(events))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
+// compile renders n as a SQL boolean expression, registering its values as
+// arguments on b. depth and nodes enforce maxFilterDepth and maxFilterNodes.
+func (b *whereBuilder) compile(n filterNode, depth int, nodes *int) (string, error) {
+	if depth > maxFilterDepth {
+		return "", fmt.Errorf("nested deeper than %d levels", maxFilterDepth)
+	}
+	if *nodes++; *nodes > maxFilterNodes {
+		return "", fmt.Errorf("more than %d nodes", maxFilterNodes)
+	}
+
+	isGroup := n.And != nil || n.Or != nil
+	isLeaf := n.Field != "" || n.Op != "" || n.Value != nil
+	switch {
+	case isGroup && isLeaf, n.And != nil && n.Or != nil:
+		return "", errors.New(`a node must be one of {"and"}, {"or"} or {"field","op","value"}`)
+	case n.And != nil:
+		return b.compileGroup(n.And, " AND ", depth, nodes)
+	case n.Or != nil:
+		return b.compileGroup(n.Or, " OR ", depth, nodes)
+	case !isLeaf:
+		return "", errors.New("empty filter")
+	}
+
+	f, ok := query.LookupColumn(n.Field)
+	if !ok {
+		return "", fmt.Errorf("unknown field %q: must be one of %s", n.Field, query.FieldNames(false))
+	}
+
+	switch n.Op {
+	case "in":
+		var raws []json.RawMessage
+		if err := json.Unmarshal(n.Value, &raws); err != nil || len(raws) == 0 {
+			return "", fmt.Errorf("%s in: value must be a non-empty array", n.Field)
+		}
+		placeholders := make([]string, len(raws))
+		for i, raw := range raws {
+			v, err := filterValue(n.Field, f.Kind, raw)
+			if err != nil {
+				return "", err
+			}
+			placeholders[i] = b.arg(v)
+		}
+		return f.Name + " IN (" + strings.Join(placeholders, ", ") + ")", nil
+	case "contains":
+		if f.Kind != "string" {
+			return "", fmt.Errorf("contains only applies to level, source and message")
+		}
+		v, err := filterValue(n.Field, f.Kind, n.Value)
+		if err != nil {
+			return "", err
+		}
+		return f.Name + " ILIKE " + b.arg("%"+likeEscaper.Replace(v.(string))+"%"), nil
+	}
+
+	op, ok := filterOps[n.Op]
+	if !ok {
+		return "", fmt.Errorf("unknown op %q: must be one of eq, ne, lt, lte, gt, gte, in, contains", n.Op)
+	}
+	v, err := filterValue(n.Field, f.Kind, n.Value)
+	if err != nil {
+		return "", err
+	}
+	return f.Name + " " + op + " " + b.arg(v), nil
+}
+
+func (b *whereBuilder) compileGroup(children []filterNode, sep string, depth int, nodes *int) (string, error) {
+	if len(children) == 0 {
+		return "", fmt.Errorf("empty%sgroup", strings.ToLower(sep))
+	}
+	parts := make([]string, len(children))
+	for i, c := range children {
+		expr, err := b.compile(c, depth+1, nodes)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = expr
+	}
+	return "(" + strings.Join(parts, sep) + ")", nil
+}
+
+// likeEscaper escapes ILIKE wildcards so contains matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterValue decodes raw as the Go value field is compared against.
+func filterValue(field, kind string, raw json.RawMessage) (interface{}, error) {
+	switch kind {
+	case "number":
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return nil, fmt.Errorf("%s: value must be an integer", field)
+		}
+		return n, nil
+	case "time":
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("%s: value must be an RFC3339 timestamp", field)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an RFC3339 timestamp", field, s)
+		}
+		return t, nil
+	default:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("%s: value must be a string", field)
+		}
+		return s, nil
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// exportFlushRows is how many rows are written between flushes to the client
+const exportFlushRows = 1000
+
+// handleExport streams every event in [from, to] as an NDJSON or CSV
+// attachment. There is no LIMIT, so both bounds are required and the span
+// is capped by QUERY_EXPORT_MAX_SPAN.
+func (app *App) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, err := query.ParseParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.From.IsZero() || p.To.IsZero() {
+		http.Error(w, "Both from and to are required for an export", http.StatusBadRequest)
+		return
+	}
+	if span := p.To.Sub(p.From); span > app.cfg.ExportMaxSpan {
+		http.Error(w, fmt.Sprintf("Export range %s exceeds the maximum of %s", span, app.cfg.ExportMaxSpan), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	var contentType string
+	switch format {
+	case "ndjson":
+		contentType = "application/x-ndjson"
+	case "csv":
+		contentType = "text/csv"
+	default:
+		http.Error(w, "format must be ndjson or csv", http.StatusBadRequest)
+		return
+	}
+
+	where := eventFilter(p)
+	stmt := "SELECT Timestamp, Level, Source, Message FROM events" + where.String() + " ORDER BY Timestamp ASC"
+
+	// Tied to the request so a client that gives up stops the scan
+	rows, err := app.db.Query(r.Context(), stmt, where.args...)
+	if err != nil {
+		log.Printf("Error executing export query: %v", err)
+		dbError(w, err, "Server error")
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("events_%s_%s.%s",
+		p.From.UTC().Format("20060102T150405Z"), p.To.UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	cw := csv.NewWriter(w)
+	if format == "csv" {
+		cw.Write([]string{"timestamp", "level", "source", "message"})
+	}
+
+	// From here on the status is sent, so failures can only be logged
+	n := 0
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.Timestamp, &e.Level, &e.Source, &e.Message); err != nil {
+			log.Printf("Error scanning export row %d: %v", n, err)
+			return
+		}
+
+		if format == "csv" {
+			cw.Write([]string{e.Timestamp.Format(app.cfg.TimeLayout), e.Level, e.Source, e.Message})
+		} else if err := enc.Encode(app.formatEvent(e)); err != nil {
+			log.Printf("Export aborted after %d rows: %v", n, err)
+			return
+		}
+
+		n++
+		if n%exportFlushRows == 0 {
+			cw.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+	cw.Flush()
+	if err := rows.Err(); err != nil {
+		log.Printf("Export aborted after %d rows: %v", n, err)
+		return
+	}
+	log.Printf("Exported %d events (%s)", n, format)
+}
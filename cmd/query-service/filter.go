@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// whereBuilder collects AND-ed conditions and numbers their placeholders.
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// add appends cond, replacing its single "?" with the next $N placeholder.
+func (b *whereBuilder) add(cond string, arg interface{}) {
+	cond = strings.Replace(cond, "?", b.arg(arg), 1)
+	b.conds = append(b.conds, cond)
+}
+
+// arg registers a bare argument (e.g. for LIMIT) and returns its placeholder.
+func (b *whereBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// String renders the WHERE clause with a leading space, or "" if empty.
+func (b *whereBuilder) String() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// eventFilter turns the shared list params into conditions on events.
+func eventFilter(p query.Params) *whereBuilder {
+	b := &whereBuilder{}
+	if !p.From.IsZero() {
+		b.add("Timestamp >= ?", p.From)
+	}
+	if !p.To.IsZero() {
+		b.add("Timestamp <= ?", p.To)
+	}
+	if p.Level != "" {
+		b.add("Level = ?", p.Level)
+	}
+	if p.Source != "" {
+		b.add("Source = ?", p.Source)
+	}
+	if p.MinSeverity > 0 {
+		b.add("Severity >= ?", p.MinSeverity)
+	}
+	return b
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// queryFlight shares one database call between identical concurrent
+// requests. The call runs detached from any one caller, so a hang-up
+// doesn't fail the others sharing it, but it is cancelled as soon as the
+// last caller waiting on it has gone. The zero value is ready to use.
+type queryFlight struct {
+	group singleflight.Group
+
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is the context of one in-flight key and who is waiting on it.
+type flightCall struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// do runs fn once for every concurrent caller of key and returns its
+// result. fn's context is cancelled when every caller's ctx is done; a
+// caller whose ctx ends first gets ctx.Err().
+func (f *queryFlight) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]*flightCall)
+	}
+	call, ok := f.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flightCall{ctx: callCtx, cancel: cancel}
+		f.calls[key] = call
+	}
+	call.waiters++
+	// Joined under f.mu, so the call can't finish between the two lookups
+	ch := f.group.DoChan(key, func() (interface{}, error) {
+		v, err := fn(call.ctx)
+		f.mu.Lock()
+		if f.calls[key] == call {
+			delete(f.calls, key)
+		}
+		f.mu.Unlock()
+		return v, err
+	})
+	f.mu.Unlock()
+	defer f.leave(key, call)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		return res.Val, res.Err
+	}
+}
+
+// leave drops one waiter from call, cancelling it when none are left.
+// A cancelled call is forgotten so later callers start afresh.
+func (f *queryFlight) leave(key string, call *flightCall) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if call.waiters--; call.waiters > 0 {
+		return
+	}
+	call.cancel()
+	if f.calls[key] == call {
+		delete(f.calls, key)
+		f.group.Forget(key)
+	}
+}
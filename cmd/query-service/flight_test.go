@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingCall is a flight fn that waits for its context, reporting when
+// it starts and when it is cancelled.
+func blockingCall(started, cancelled chan struct{}) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+}
+
+func waitFor(t *testing.T, ch chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestFlightLoneCancelCancelsQuery(t *testing.T) {
+	var f queryFlight
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := f.do(ctx, "k", blockingCall(started, cancelled))
+		errc <- err
+	}()
+	waitFor(t, started, "the query to start")
+	cancel()
+
+	waitFor(t, cancelled, "the query to be cancelled")
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("do = %v; want context.Canceled", err)
+	}
+}
+
+func TestFlightSharedQueryOutlivesOneCaller(t *testing.T) {
+	var f queryFlight
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	fn := blockingCall(started, cancelled)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	done1, done2 := make(chan struct{}), make(chan struct{})
+	go func() { f.do(ctx1, "k", fn); close(done1) }()
+	waitFor(t, started, "the query to start")
+	go func() { f.do(ctx2, "k", fn); close(done2) }()
+
+	// Wait until the second caller has joined
+	for {
+		f.mu.Lock()
+		n := f.calls["k"].waiters
+		f.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel1()
+	waitFor(t, done1, "the first caller to return")
+	select {
+	case <-cancelled:
+		t.Fatal("query cancelled while a caller was still waiting on it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel2()
+	waitFor(t, cancelled, "the query to be cancelled")
+	waitFor(t, done2, "the second caller to return")
+}
+
+func TestFlightCoalescesAndStartsAfresh(t *testing.T) {
+	var f queryFlight
+	var runs atomic.Int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		runs.Add(1)
+		<-release
+		return "rows", nil
+	}
+
+	results := make(chan interface{}, 3)
+	for range 3 {
+		go func() {
+			v, _ := f.do(context.Background(), "k", fn)
+			results <- v
+		}()
+	}
+	for {
+		f.mu.Lock()
+		n := 0
+		if c := f.calls["k"]; c != nil {
+			n = c.waiters
+		}
+		f.mu.Unlock()
+		if n == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for range 3 {
+		if v := <-results; v != "rows" {
+			t.Errorf("got %v; want rows", v)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("fn ran %d times; want 1", n)
+	}
+
+	// A finished call is not reused
+	f.do(context.Background(), "k", fn)
+	if n := runs.Load(); n != 2 {
+		t.Errorf("fn ran %d times after a new call; want 2", n)
+	}
+	if len(f.calls) != 0 {
+		t.Errorf("%d calls left in flight", len(f.calls))
+	}
+}
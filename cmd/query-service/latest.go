@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// handleLatestPerSource returns the newest event of every source, ordered
+// by source, as a quick view of which services are still logging. The
+// level, min_severity, from and to filters narrow the events considered, and
+// limit caps the sources returned like on the other list endpoints.
+func (app *App) handleLatestPerSource(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, err := query.ParseParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// DISTINCT ON keeps the first row per Source in ORDER BY order
+	where := eventFilter(p)
+	stmt := "SELECT DISTINCT ON (Source) Timestamp, Level, Source, Message FROM events" + where.String() +
+		" ORDER BY Source, Timestamp DESC LIMIT " + where.arg(app.capLimit(p.Limit))
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.cfg.QueryTimeout)
+	defer cancel()
+
+	rows, err := app.db.Query(ctx, stmt, where.args...)
+	if err != nil {
+		log.Printf("Error executing latest-per-source query: %v", err)
+		queryError(ctx, w, err, "Server error")
+		return
+	}
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Event])
+	if err != nil {
+		log.Printf("Error scanning rows: %v", err)
+		queryError(ctx, w, err, "Server error")
+		return
+	}
+
+	app.setRowCapHeaders(w, p.Limit, len(events))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(app.formatEvents(events))
+}
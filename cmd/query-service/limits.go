@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// capLimit clamps a requested row limit to QUERY_HARD_MAX_ROWS.
+func (app *App) capLimit(limit int) int {
+	return min(limit, app.cfg.HardMaxRows)
+}
+
+// setRowCapHeaders advertises the hard cap on every list response and sets
+// X-Result-Truncated when the client asked for more than the cap and got
+// a full page, i.e. more rows may have matched than were returned.
+func (app *App) setRowCapHeaders(w http.ResponseWriter, requested, returned int) {
+	w.Header().Set("X-Result-Max-Rows", strconv.Itoa(app.cfg.HardMaxRows))
+	if requested > app.cfg.HardMaxRows && returned >= app.cfg.HardMaxRows {
+		w.Header().Set("X-Result-Truncated", "true")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// levelCountsSQL counts events per level over the trailing minute
+const levelCountsSQL = `SELECT COALESCE(Level, ''), count(*) FROM events WHERE Timestamp > now() - interval '1 minute' GROUP BY Level`
+
+// liveWriteWait bounds how long a single frame write may block
+const liveWriteWait = 10 * time.Second
+
+// liveHub runs the level-count query once per tick and fans the snapshot
+// out to every connected client, so N open dashboards cost one query.
+type liveHub struct {
+	db       *pgxpool.Pool
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+}
+
+func newLiveHub(db *pgxpool.Pool, upgrader websocket.Upgrader) *liveHub {
+	return &liveHub{db: db, upgrader: upgrader, clients: make(map[chan []byte]struct{})}
+}
+
+// run broadcasts a snapshot every interval until ctx is cancelled.
+func (h *liveHub) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.broadcast(ctx)
+		}
+	}
+}
+
+func (h *liveHub) broadcast(ctx context.Context) {
+	h.mu.Lock()
+	idle := len(h.clients) == 0
+	h.mu.Unlock()
+	if idle {
+		return // Nobody listening, skip the query
+	}
+
+	frame, err := h.snapshot(ctx)
+	if err != nil {
+		log.Printf("Error building live level counts: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		select {
+		case c <- frame:
+		default:
+			// Client hasn't taken the last frame yet; it gets the next one
+		}
+	}
+}
+
+// snapshot returns the current counts as a JSON object, e.g. {"ERROR":3}.
+func (h *liveHub) snapshot(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := h.db.Query(ctx, levelCountsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var level string
+		var n int64
+		if err := rows.Scan(&level, &n); err != nil {
+			return nil, err
+		}
+		counts[level] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(counts)
+}
+
+// handle upgrades the request and streams snapshots until the client leaves.
+func (h *liveHub) handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return // Upgrade has already replied to the client
+	}
+	defer conn.Close()
+
+	frames := make(chan []byte, 1)
+	h.mu.Lock()
+	h.clients[frames] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, frames)
+		h.mu.Unlock()
+	}()
+
+	// Send one snapshot straight away instead of waiting a full interval.
+	// Nothing drains frames yet, so don't block: if a broadcast got in
+	// during the query, its frame is just as fresh
+	if frame, err := h.snapshot(r.Context()); err == nil {
+		select {
+		case frames <- frame:
+		default:
+		}
+	}
+
+	// We never expect messages; reading only detects the client going away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case frame := <-frames:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -3,42 +3,124 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	// Update this to your go.mod module name
+	"github.com/rajindersingh041/go-microservices/internal/compression"
+	"github.com/rajindersingh041/go-microservices/internal/cors"
 	"github.com/rajindersingh041/go-microservices/internal/database"
+	"github.com/rajindersingh041/go-microservices/internal/metrics"
 	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/openapi"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+	"github.com/rajindersingh041/go-microservices/internal/wait"
 )
 
 // App holds the concurrent-safe connection pool
 type App struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	cfg    Config
+	live   *liveHub
+	flight queryFlight
 }
 
-// Includes the fix: func main()
 func main() {
-	// Connect to Postgres pool (also runs init sql)
-	conn, err := database.Connect()
+	if err := run(); err != nil {
+		log.Printf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the service and blocks until the server stops. Errors are
+// returned rather than fatal so the deferred pool Close always runs.
+func run() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Connect to Postgres pool (also runs init sql). Reads may go to a
+	// replica via POSTGRES_READ_HOST; the schema is then left to ingestion,
+	// which owns the primary.
+	host, replica := database.ReadHost()
+	if cfg.WaitTimeout > 0 {
+		addr := database.Addr(host)
+		log.Printf("Waiting up to %s for Postgres at %s...", cfg.WaitTimeout, addr)
+		if err := wait.WaitForTCP(addr, cfg.WaitTimeout); err != nil {
+			return fmt.Errorf("postgres not reachable: %w", err)
+		}
+	}
+	conn, err := database.ConnectTo(host, !replica)
 	if err != nil {
-		log.Fatalf("Failed to connect to Postgres: %v", err)
+		return fmt.Errorf("failed to connect to Postgres: %w", err)
 	}
 	defer conn.Close() // Closes the pool on shutdown
 
+	if replica {
+		log.Printf("Reading from replica %s; skipping schema migrations.", host)
+	} else {
+		// Apply any pending schema migrations
+		if err := database.Migrate(context.Background(), conn); err != nil {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+
 	log.Println("Successfully connected to Postgres pool and schema is ready.")
 
-	app := &App{db: conn}
+	// Browsers don't apply CORS to WebSocket upgrades, so the live feed
+	// checks Origin against the same CORS_ALLOWED_ORIGINS itself
+	upgrader := websocket.Upgrader{CheckOrigin: cors.CheckOrigin(cfg.CORSOrigins)}
+	app := &App{db: conn, cfg: cfg, live: newLiveHub(conn, upgrader)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.live.run(ctx, cfg.LiveInterval)
 
 	http.HandleFunc("/query", app.handleQuery)
+	http.HandleFunc("/query/export", app.handleExport)
+	http.HandleFunc("/query/archive", app.handleArchive)
+	http.HandleFunc("/query/since", app.handleSince)
+	http.HandleFunc("/query/poll", app.handlePoll)
+	http.HandleFunc("/query/filter", app.handleFilter)
+	http.HandleFunc("/query/latest-per-source", app.handleLatestPerSource)
+	http.HandleFunc("/query/summary", app.handleSummary)
+	http.HandleFunc("/query/histogram", app.handleHistogram)
+	http.HandleFunc("/query/levels/live", app.live.handle)
+	http.HandleFunc("/readyz", app.handleReady)
+	http.HandleFunc("/openapi.json", openapi.Handler)
+
+	// Pool saturation gauges, read from pool.Stat() on every scrape
+	prometheus.MustRegister(metrics.NewPoolCollector(conn))
+	http.Handle("/metrics", promhttp.Handler())
+
+	var handler http.Handler = compression.Handler(http.DefaultServeMux, compression.Options{
+		MinBytes: cfg.GzipMinBytes,
+		Level:    cfg.GzipLevel,
+	})
+	log.Printf("Gzip enabled for responses >= %d bytes at level %d", cfg.GzipMinBytes, cfg.GzipLevel)
+	if len(cfg.CORSOrigins) > 0 {
+		handler = cors.Handler(handler, cors.Options{AllowedOrigins: cfg.CORSOrigins, MaxAge: cfg.CORSMaxAge})
+		log.Printf("CORS enabled for %v (preflight max-age %s)", cfg.CORSOrigins, cfg.CORSMaxAge)
+	}
 
 	port := ":8081"
 	log.Printf("Starting query service on port %s...", port)
-	if err := http.ListenAndServe(port, nil); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	if err := http.ListenAndServe(port, handler); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
 	}
+	return nil
 }
 
 func (app *App) handleQuery(w http.ResponseWriter, r *http.Request) {
@@ -47,27 +129,140 @@ func (app *App) handleQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := "SELECT Timestamp, Level, Source, Message FROM events ORDER BY Timestamp DESC LIMIT 10"
+	// ?fields= narrows the columns; names are checked against a whitelist
+	columns, projected, err := query.ParseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// app.db.Query() is concurrency-safe
-	rows, err := app.db.Query(context.Background(), query)
+	p, err := query.ParseParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	envelope, err := query.WantsEnvelope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Identical concurrent requests share one database round trip. The key
+	// is taken before the default window is applied, as that moves with now
+	key := fmt.Sprintf("%v|%t|%t|%+v", columns, projected, envelope, p)
+
+	// Without explicit bounds, only look back over the default window
+	if app.cfg.DefaultWindow > 0 && p.From.IsZero() && p.To.IsZero() {
+		p.From = time.Now().Add(-app.cfg.DefaultWindow)
+	}
+
+	where := eventFilter(p)
+	filterSQL, filterArgs := where.String(), where.args
+	stmt := "SELECT " + strings.Join(columns, ", ") + " FROM events" + filterSQL +
+		" ORDER BY Timestamp DESC LIMIT " + where.arg(app.capLimit(p.Limit)) + " OFFSET " + where.arg(p.Offset)
+
+	val, err := app.flight.do(r.Context(), key, func(ctx context.Context) (interface{}, error) {
+		// Cancelled once every request sharing it has gone; QUERY_TIMEOUT
+		// bounds it either way
+		ctx, cancel := context.WithTimeout(ctx, app.cfg.QueryTimeout)
+		defer cancel()
+
+		res, err := app.runQuery(ctx, stmt, where.args, projected)
+		if err == nil && envelope {
+			res.total, err = app.countEvents(ctx, filterSQL, filterArgs)
+		}
+		if err != nil && ctx.Err() != nil {
+			err = fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		return res, err
+	})
+
+	if r.Context().Err() != nil {
+		return // Client went away
+	}
 	if err != nil {
 		log.Printf("Error executing query: %v", err)
-		http.Error(w, "Server error", http.StatusInternalServerError)
+		queryError(r.Context(), w, err, "Server error")
 		return
 	}
+	result := val.(queryResult)
+
+	body := app.formatResult(result)
+	if envelope {
+		body = query.NewEnvelope(body, app.capLimit(p.Limit), p.Offset, result.count, result.total)
+	}
+
+	app.setRowCapHeaders(w, p.Limit, result.count)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
+// queryResult is a /query result as shared between coalesced requests; it
+// must not be modified.
+type queryResult struct {
+	rows  interface{}
+	count int
+	// total is every matching row, ignoring limit and offset; it is only
+	// counted for ?envelope=true
+	total int64
+}
+
+// formatResult applies QUERY_TIME_FORMAT to a (possibly shared) result.
+func (app *App) formatResult(res queryResult) interface{} {
+	switch rows := res.rows.(type) {
+	case []models.Event:
+		return app.formatEvents(rows)
+	case []map[string]interface{}:
+		return app.formatMaps(rows)
+	}
+	return res.rows
+}
+
+// runQuery executes a /query statement. A projection is collected as maps
+// so only the requested keys appear.
+func (app *App) runQuery(ctx context.Context, stmt string, args []interface{}, projected bool) (queryResult, error) {
+	// app.db.Query() is concurrency-safe
+	rows, err := app.db.Query(ctx, stmt, args...)
+	if err != nil {
+		return queryResult{}, err
+	}
 	defer rows.Close()
 
+	if projected {
+		maps, err := pgx.CollectRows(rows, pgx.RowToMap)
+		return queryResult{rows: maps, count: len(maps)}, err
+	}
 	// working now, earlier wasnot working
 	events, err := pgx.CollectRows[models.Event](rows, pgx.RowToStructByName[models.Event])
-		
-	if err != nil {
-		log.Printf("Error scanning rows: %v", err)
-		http.Error(w, "Server error", http.StatusInternalServerError)
+	return queryResult{rows: events, count: len(events)}, err
+}
+
+// countEvents counts the events matching a WHERE clause from eventFilter
+// or the filter DSL, for the envelope's total.
+func (app *App) countEvents(ctx context.Context, where string, args []interface{}) (int64, error) {
+	var total int64
+	err := app.db.QueryRow(ctx, "SELECT count(*) FROM events"+where, args...).Scan(&total)
+	return total, err
+}
+
+// queryError answers a failed query: 504 when ctx, or the shared query
+// behind err, hit its deadline, otherwise whatever dbError decides.
+func queryError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		http.Error(w, "Timed out querying the database", http.StatusGatewayTimeout)
 		return
 	}
+	dbError(w, err, msg)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(events)
-}
\ No newline at end of file
+// dbError answers a failed database call: 503 with Retry-After when Postgres
+// is unreachable, otherwise a 500 carrying msg.
+func dbError(w http.ResponseWriter, err error, msg string) {
+	if database.IsUnavailable(err) {
+		w.Header().Set("Retry-After", database.RetryAfterSeconds)
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// readyTables are the tables this service reads. A ping alone would report
+// ready even after one of them was dropped.
+var readyTables = []string{"events"}
+
+// undefinedTable is the Postgres SQLSTATE for a missing relation
+const undefinedTable = "42P01"
+
+// handleReady answers 200 only when every table in readyTables is queryable.
+func (app *App) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	for _, table := range readyTables {
+		// LIMIT 0 plans against the table without reading any rows
+		_, err := app.db.Exec(ctx, "SELECT 1 FROM "+pgx.Identifier{table}.Sanitize()+" LIMIT 0")
+		if err == nil {
+			continue
+		}
+
+		body := map[string]string{"status": "not ready"}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == undefinedTable {
+			body["missing_table"] = table
+		} else {
+			body["error"] = "database unavailable"
+		}
+		log.Printf("Readiness check failed on %s: %v", table, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+}
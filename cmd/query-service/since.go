@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// Long-poll bounds for /query/poll
+const (
+	defaultPollWait  = 30 * time.Second
+	maxPollWait      = 60 * time.Second
+	pollRecheckEvery = 500 * time.Millisecond
+)
+
+// sinceResponse is one page of /query/since or /query/poll. NextCursor is
+// the position of the last event returned, or the request's cursor when
+// there were none, so a poller can always pass it straight back.
+type sinceResponse struct {
+	Events     []eventJSON `json:"events"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// handleSince returns events strictly after ?cursor, oldest first, so a
+// client tailing the table only ever fetches what it hasn't seen yet.
+// The usual limit, level, source and min_severity filters apply.
+func (app *App) handleSince(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	raw, cursor, p, ok := parseCursorParams(w, r, "cursor")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.cfg.QueryTimeout)
+	defer cancel()
+
+	events, next, err := app.eventsSince(ctx, p, cursor)
+	if err != nil {
+		log.Printf("Error executing since query: %v", err)
+		queryError(ctx, w, err, "Server error")
+		return
+	}
+	app.writeSince(w, p, raw, events, next)
+}
+
+// handlePoll is the long-polling form of /query/since for clients behind
+// proxies that break streaming: it holds the request until events after
+// the ?since cursor exist or ?wait (default 30s, max 60s) runs out,
+// re-checking every half second. An empty page means the wait expired.
+func (app *App) handlePoll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	raw, cursor, p, ok := parseCursorParams(w, r, "since")
+	if !ok {
+		return
+	}
+	wait := defaultPollWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 || d > maxPollWait {
+			http.Error(w, (&query.Error{Param: "wait", Reason: "must be a duration between 0s and " + maxPollWait.String()}).Error(), http.StatusBadRequest)
+			return
+		}
+		wait = d
+	}
+
+	deadline := time.Now().Add(wait)
+	ticker := time.NewTicker(pollRecheckEvery)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(r.Context(), app.cfg.QueryTimeout)
+		events, next, err := app.eventsSince(ctx, p, cursor)
+		if err != nil {
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				cancel()
+				return // Client went away
+			}
+			log.Printf("Error executing poll query: %v", err)
+			queryError(ctx, w, err, "Server error")
+			cancel()
+			return
+		}
+		cancel()
+
+		if len(events) > 0 || !time.Now().Before(deadline) {
+			app.writeSince(w, p, raw, events, next)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// eventCursor is a position in (Timestamp, id) order, written as
+// "<RFC3339Nano>,<id>". Paging on the id too means rows sharing a
+// timestamp, e.g. a batch stamped with one ingest time, are neither skipped
+// nor repeated across pages. A bare timestamp, with ID 0, means everything
+// after that instant.
+type eventCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+func parseEventCursor(raw string) (eventCursor, error) {
+	ts, id, hasID := strings.Cut(raw, ",")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return eventCursor{}, errors.New("not an RFC3339 timestamp")
+	}
+	c := eventCursor{Timestamp: t}
+	if hasID {
+		if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil || c.ID < 1 {
+			return eventCursor{}, fmt.Errorf("%q is not an event id", id)
+		}
+	}
+	return c, nil
+}
+
+func (c eventCursor) String() string {
+	return c.Timestamp.UTC().Format(time.RFC3339Nano) + "," + strconv.FormatInt(c.ID, 10)
+}
+
+// parseCursorParams reads the required cursor param name plus the shared
+// list params, answering 400 itself when either is invalid.
+func parseCursorParams(w http.ResponseWriter, r *http.Request, name string) (string, eventCursor, query.Params, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		http.Error(w, name+" is required", http.StatusBadRequest)
+		return "", eventCursor{}, query.Params{}, false
+	}
+	cursor, err := parseEventCursor(raw)
+	if err != nil {
+		http.Error(w, (&query.Error{Param: name, Reason: err.Error()}).Error(), http.StatusBadRequest)
+		return "", eventCursor{}, query.Params{}, false
+	}
+
+	p, err := query.ParseParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", eventCursor{}, query.Params{}, false
+	}
+	return raw, cursor, p, true
+}
+
+// eventsSince fetches up to the (capped) limit of events after cursor in
+// (Timestamp, id) order, and the cursor of the last one.
+func (app *App) eventsSince(ctx context.Context, p query.Params, cursor eventCursor) ([]models.Event, eventCursor, error) {
+	where := eventFilter(p)
+	if cursor.ID == 0 {
+		where.add("Timestamp > ?", cursor.Timestamp)
+	} else {
+		where.conds = append(where.conds,
+			"(Timestamp, id) > ("+where.arg(cursor.Timestamp)+", "+where.arg(cursor.ID)+")")
+	}
+	stmt := "SELECT id, Timestamp, Level, Source, Message FROM events" + where.String() +
+		" ORDER BY Timestamp ASC, id ASC LIMIT " + where.arg(app.capLimit(p.Limit))
+
+	rows, err := app.db.Query(ctx, stmt, where.args...)
+	if err != nil {
+		return nil, cursor, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&cursor.ID, &e.Timestamp, &e.Level, &e.Source, &e.Message); err != nil {
+			return nil, cursor, err
+		}
+		cursor.Timestamp = e.Timestamp
+		events = append(events, e)
+	}
+	return events, cursor, rows.Err()
+}
+
+// writeSince encodes events with next, the cursor after them.
+func (app *App) writeSince(w http.ResponseWriter, p query.Params, raw string, events []models.Event, next eventCursor) {
+	resp := sinceResponse{Events: app.formatEvents(events), NextCursor: raw}
+	if len(events) > 0 {
+		resp.NextCursor = next.String()
+	}
+
+	app.setRowCapHeaders(w, p.Limit, len(events))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
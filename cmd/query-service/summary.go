@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// handleSummary counts events per source and level and returns them as a
+// tree, e.g. {"payment-svc":{"ERROR":5,"WARN":2},"auth-svc":{"INFO":100}},
+// for a collapsible drill-down. from, to, level, source and min_severity
+// narrow the events counted.
+func (app *App) handleSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, err := query.ParseParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	where := eventFilter(p)
+	stmt := "SELECT COALESCE(Source, ''), COALESCE(Level, ''), count(*) FROM events" + where.String() +
+		" GROUP BY 1, 2"
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.cfg.QueryTimeout)
+	defer cancel()
+
+	rows, err := app.db.Query(ctx, stmt, where.args...)
+	if err != nil {
+		log.Printf("Error executing summary query: %v", err)
+		queryError(ctx, w, err, "Server error")
+		return
+	}
+	defer rows.Close()
+
+	tree := make(map[string]map[string]int64)
+	for rows.Next() {
+		var source, level string
+		var n int64
+		if err := rows.Scan(&source, &level, &n); err != nil {
+			log.Printf("Error scanning summary row: %v", err)
+			queryError(ctx, w, err, "Server error")
+			return
+		}
+		if tree[source] == nil {
+			tree[source] = make(map[string]int64)
+		}
+		tree[source][level] = n
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error reading summary rows: %v", err)
+		queryError(ctx, w, err, "Server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tree)
+}
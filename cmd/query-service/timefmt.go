@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+// timeFormats are the QUERY_TIME_FORMAT names accepted besides a custom
+// Go layout.
+var timeFormats = map[string]string{
+	"RFC3339":      time.RFC3339,
+	"RFC3339Milli": "2006-01-02T15:04:05.000Z07:00",
+	"RFC3339Micro": "2006-01-02T15:04:05.000000Z07:00",
+	"RFC3339Nano":  time.RFC3339Nano,
+}
+
+// parseTimeFormat resolves QUERY_TIME_FORMAT: one of the timeFormats names
+// or a Go reference-time layout such as "2006-01-02 15:04:05".
+func parseTimeFormat(v string) (string, error) {
+	if layout, ok := timeFormats[v]; ok {
+		return layout, nil
+	}
+	if strings.Contains(v, "2006") {
+		return v, nil
+	}
+	return "", fmt.Errorf("QUERY_TIME_FORMAT must be RFC3339, RFC3339Milli, RFC3339Micro, RFC3339Nano or a Go layout, got %q", v)
+}
+
+// jsonTime encodes a timestamp with the configured layout instead of
+// time.Time's RFC3339Nano, so every endpoint prints the same precision.
+type jsonTime struct {
+	t      time.Time
+	layout string
+}
+
+func (j jsonTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.t.Format(j.layout))
+}
+
+// eventJSON is models.Event as query-service encodes it.
+type eventJSON struct {
+	Timestamp jsonTime `json:"timestamp"`
+	Level     string   `json:"level"`
+	Source    string   `json:"source"`
+	Message   string   `json:"message"`
+}
+
+func (app *App) jsonTime(t time.Time) jsonTime {
+	return jsonTime{t: t, layout: app.cfg.TimeLayout}
+}
+
+func (app *App) formatEvent(e models.Event) eventJSON {
+	return eventJSON{Timestamp: app.jsonTime(e.Timestamp), Level: e.Level, Source: e.Source, Message: e.Message}
+}
+
+// formatEvents converts events for encoding. The input is not modified.
+func (app *App) formatEvents(events []models.Event) []eventJSON {
+	out := make([]eventJSON, len(events))
+	for i, e := range events {
+		out[i] = app.formatEvent(e)
+	}
+	return out
+}
+
+// formatMaps converts the time values of projected rows for encoding. The
+// input may be shared between coalesced requests, so it is copied.
+func (app *App) formatMaps(rows []map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		m := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			if t, ok := v.(time.Time); ok {
+				v = app.jsonTime(t)
+			}
+			m[k] = v
+		}
+		out[i] = m
+	}
+	return out
+}
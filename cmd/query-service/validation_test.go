@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The handlers must reject bad column names before touching the database,
+// so an App without a pool is enough here.
+func newTestApp() *App {
+	return &App{cfg: Config{QueryTimeout: time.Second, HardMaxRows: 1000}}
+}
+
+func TestQueryRejectsInjectedFields(t *testing.T) {
+	app := newTestApp()
+	for _, fields := range []string{"1;DROP", "level;DROP TABLE events", "message,(SELECT 1)", "severity"} {
+		req := httptest.NewRequest(http.MethodGet, "/query?"+url.Values{"fields": {fields}}.Encode(), nil)
+		rec := httptest.NewRecorder()
+		app.handleQuery(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("fields=%q: status %d, want 400", fields, rec.Code)
+		}
+	}
+}
+
+// A raw semicolon makes the query string unparseable; it must not be
+// dropped and the query run unprojected.
+func TestQueryRejectsRawSemicolon(t *testing.T) {
+	app := newTestApp()
+	for _, target := range []string{"/query?fields=1;DROP", "/query?limit=5;DROP%20TABLE%20events"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		app.handleQuery(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, rec.Code)
+		}
+	}
+}
+
+func TestFilterRejectsUnknownFields(t *testing.T) {
+	app := newTestApp()
+	for _, body := range []string{
+		`{"where":{"field":"1;DROP","op":"eq","value":"x"}}`,
+		`{"where":{"field":"level; DROP TABLE events","op":"eq","value":"x"}}`,
+		`{"where":{"or":[{"field":"level","op":"eq","value":"ERROR"},{"field":"id","op":"gt","value":1}]}}`,
+		`{"where":{"field":"level","op":"= 1 OR 1","value":"x"}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/query/filter", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		app.handleFilter(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, rec.Code)
+		}
+	}
+}
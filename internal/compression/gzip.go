@@ -0,0 +1,158 @@
+// Package compression gzips HTTP responses for clients that accept it.
+package compression
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// Options configures Handler.
+type Options struct {
+	// MinBytes is the smallest response worth compressing; anything that
+	// finishes below it is sent as-is.
+	MinBytes int
+	// Level is a compress/gzip level, e.g. gzip.DefaultCompression.
+	Level int
+}
+
+// ValidLevel reports whether level is accepted by compress/gzip.
+func ValidLevel(level int) bool {
+	return level >= gzip.HuffmanOnly && level <= gzip.BestCompression
+}
+
+// Handler gzips responses from next once they reach opts.MinBytes.
+// WebSocket upgrades are passed through untouched.
+func Handler(next http.Handler, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Header.Get("Upgrade") != "" || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &responseWriter{ResponseWriter: w, opts: opts}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// compressed reports whether contentType is already compressed, so gzip
+// would only cost CPU.
+func compressed(contentType string) bool {
+	switch strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]) {
+	case "application/zip", "application/gzip":
+		return true
+	}
+	return false
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(enc, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// responseWriter buffers the start of a response until it knows whether the
+// body reaches MinBytes, then commits to either gzip or plain output.
+type responseWriter struct {
+	http.ResponseWriter
+	opts    Options
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.opts.MinBytes {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush commits to gzip: a handler that flushes is streaming a body that is
+// unlikely to stay small.
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		w.start(true)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start writes the status line and buffered bytes, compressed or not.
+func (w *responseWriter) start(compress bool) error {
+	w.decided = true
+	h := w.Header()
+
+	if compress && h.Get("Content-Encoding") == "" && !compressed(h.Get("Content-Type")) {
+		// Sniff from the plain bytes; net/http would otherwise sniff gzip
+		if h.Get("Content-Type") == "" {
+			h.Set("Content-Type", http.DetectContentType(w.buf))
+		}
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.opts.Level)
+		if err != nil {
+			return err
+		}
+		w.gz = gz
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.gz != nil {
+		_, err := w.gz.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// close sends a response that stayed under MinBytes uncompressed and
+// finishes the gzip stream otherwise.
+func (w *responseWriter) close() {
+	if !w.decided {
+		w.start(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
@@ -0,0 +1,99 @@
+// Package cors adds CORS response headers and answers browser preflights.
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configures Handler.
+type Options struct {
+	// AllowedOrigins lists exact origins, or "*" for any origin.
+	AllowedOrigins []string
+	// MaxAge lets browsers cache a preflight result, cutting OPTIONS traffic
+	// from dashboards that poll.
+	MaxAge time.Duration
+}
+
+// Handler wraps next with CORS support. Requests without an Origin header,
+// or from an origin that isn't allowed, pass through untouched.
+func Handler(next http.Handler, opts Options) http.Handler {
+	anyOrigin := false
+	allowed := make(map[string]bool, len(opts.AllowedOrigins))
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			anyOrigin = true
+		}
+		allowed[o] = true
+	}
+	maxAge := strconv.Itoa(int(opts.MaxAge / time.Second))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if !anyOrigin {
+			// The response depends on Origin, so shared caches must key on it
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin == "" || !(anyOrigin || allowed[origin]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		if h := r.Header.Get("Access-Control-Request-Headers"); h != "" {
+			w.Header().Set("Access-Control-Allow-Headers", h)
+		}
+		w.Header().Set("Access-Control-Max-Age", maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ParseOrigins splits a comma-separated origin list, dropping blanks.
+func ParseOrigins(s string) []string {
+	var origins []string
+	for _, o := range strings.Split(s, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+// CheckOrigin returns a WebSocket origin check for the same origins
+// Handler allows. Upgrades aren't covered by CORS, so without it a
+// dashboard on an allowed origin would be refused. Requests with no Origin
+// header and same-origin requests are always accepted.
+func CheckOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	anyOrigin := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, o := range allowedOrigins {
+		if o == "*" {
+			anyOrigin = true
+		}
+		allowed[o] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || anyOrigin || allowed[origin] {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
@@ -0,0 +1,30 @@
+package cors
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{nil, "", true},
+		{nil, "http://query.local:8081", true}, // same origin
+		{nil, "https://dash.example.com", false},
+		{[]string{"https://dash.example.com"}, "https://dash.example.com", true},
+		{[]string{"https://dash.example.com"}, "https://evil.example.com", false},
+		{[]string{"*"}, "https://anything.example.com", true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://query.local:8081/query/levels/live", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := CheckOrigin(tt.allowed)(r); got != tt.want {
+			t.Errorf("CheckOrigin(%v) with Origin %q = %t; want %t", tt.allowed, tt.origin, got, tt.want)
+		}
+	}
+}
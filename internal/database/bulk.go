@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Beginner starts transactions; *pgxpool.Pool and *pgx.Conn both satisfy
+// it.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// BulkInsert loads n rows into table with one COPY per chunk rows, all
+// inside a single transaction, and returns the total written. row builds
+// row i, one value per column in cols, on demand, so the whole batch never
+// has to be materialised at once. If any chunk fails the transaction is
+// rolled back and nothing is kept. A chunk of 0 or less copies everything
+// in one go.
+func BulkInsert(ctx context.Context, db Beginner, table string, cols []string, n, chunk int, row func(i int) ([]any, error)) (int64, error) {
+	if chunk <= 0 {
+		chunk = n
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin bulk insert: %w", err)
+	}
+	// A no-op once Commit has succeeded
+	defer tx.Rollback(context.Background())
+
+	var total int64
+	for start := 0; start < n; start += chunk {
+		end := min(start+chunk, n)
+		copied, err := tx.CopyFrom(ctx, pgx.Identifier{table}, cols,
+			pgx.CopyFromSlice(end-start, func(i int) ([]any, error) {
+				return row(start + i)
+			}))
+		if err != nil {
+			return 0, err
+		}
+		total += copied
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit bulk insert: %w", err)
+	}
+	return total, nil
+}
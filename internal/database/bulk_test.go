@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var errFake = errors.New("fake failure")
+
+// fakeDB hands out tx, or fails Begin with beginErr.
+type fakeDB struct {
+	tx       *fakeTx
+	beginErr error
+}
+
+func (f *fakeDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	if f.beginErr != nil {
+		return nil, f.beginErr
+	}
+	return f.tx, nil
+}
+
+// fakeTx records each CopyFrom call. Methods the helpers don't use are
+// left to the nil embedded pgx.Tx and panic if called.
+type fakeTx struct {
+	pgx.Tx
+
+	failCopy  int // 1-based CopyFrom call that fails; 0 never fails
+	commitErr error
+
+	table      pgx.Identifier
+	cols       []string
+	copies     [][][]any
+	committed  bool
+	rolledBack bool
+}
+
+func (t *fakeTx) CopyFrom(ctx context.Context, table pgx.Identifier, cols []string, src pgx.CopyFromSource) (int64, error) {
+	t.table, t.cols = table, cols
+	if len(t.copies)+1 == t.failCopy {
+		return 0, errFake
+	}
+	var rows [][]any
+	for src.Next() {
+		v, err := src.Values()
+		if err != nil {
+			return 0, err
+		}
+		rows = append(rows, v)
+	}
+	t.copies = append(t.copies, rows)
+	return int64(len(rows)), nil
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	if t.commitErr != nil {
+		return t.commitErr
+	}
+	t.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if t.committed {
+		return pgx.ErrTxClosed
+	}
+	t.rolledBack = true
+	return nil
+}
+
+// intRow builds row i as just i.
+func intRow(i int) ([]any, error) { return []any{i}, nil }
+
+func chunkSizes(copies [][][]any) []int {
+	sizes := make([]int, len(copies))
+	for i, c := range copies {
+		sizes[i] = len(c)
+	}
+	return sizes
+}
+
+func TestBulkInsertCopiesIntoTable(t *testing.T) {
+	tx := &fakeTx{}
+	rows := [][]any{{"a", 1}, {"b", 2}}
+	n, err := BulkInsert(context.Background(), &fakeDB{tx: tx}, "events", []string{"source", "severity"}, len(rows), 0,
+		func(i int) ([]any, error) { return rows[i], nil })
+	if err != nil || n != 2 {
+		t.Fatalf("BulkInsert = %d, %v; want 2, nil", n, err)
+	}
+	if !tx.committed || tx.rolledBack {
+		t.Errorf("committed=%t rolledBack=%t; want a commit only", tx.committed, tx.rolledBack)
+	}
+	if !reflect.DeepEqual(tx.table, pgx.Identifier{"events"}) || !reflect.DeepEqual(tx.cols, []string{"source", "severity"}) {
+		t.Errorf("copied into %v%v", tx.table, tx.cols)
+	}
+	if !reflect.DeepEqual(tx.copies, [][][]any{rows}) {
+		t.Errorf("copies = %v; want %v", tx.copies, rows)
+	}
+}
+
+func TestBulkInsertSplitsRows(t *testing.T) {
+	tests := []struct {
+		n, chunk int
+		sizes    []int
+	}{
+		{7, 3, []int{3, 3, 1}},
+		{6, 3, []int{3, 3}},
+		{2, 10, []int{2}},
+		{5, 0, []int{5}},
+		{0, 3, []int{}},
+	}
+	for _, tt := range tests {
+		tx := &fakeTx{}
+		n, err := BulkInsert(context.Background(), &fakeDB{tx: tx}, "events", []string{"i"}, tt.n, tt.chunk, intRow)
+		if err != nil || n != int64(tt.n) {
+			t.Errorf("n=%d chunk=%d: got %d, %v", tt.n, tt.chunk, n, err)
+			continue
+		}
+		if got := chunkSizes(tx.copies); !reflect.DeepEqual(got, tt.sizes) {
+			t.Errorf("n=%d chunk=%d: chunk sizes %v, want %v", tt.n, tt.chunk, got, tt.sizes)
+		}
+		// Rows arrive in order across chunk boundaries
+		i := 0
+		for _, c := range tx.copies {
+			for _, row := range c {
+				if row[0] != i {
+					t.Fatalf("n=%d chunk=%d: row %d holds %v", tt.n, tt.chunk, i, row[0])
+				}
+				i++
+			}
+		}
+		if !tx.committed {
+			t.Errorf("n=%d chunk=%d: not committed", tt.n, tt.chunk)
+		}
+	}
+}
+
+func TestBulkInsertRollsBack(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   *fakeTx
+		row  func(i int) ([]any, error)
+	}{
+		{"chunk fails", &fakeTx{failCopy: 2}, intRow},
+		{"row fails", &fakeTx{}, func(i int) ([]any, error) {
+			if i == 4 {
+				return nil, errFake
+			}
+			return []any{i}, nil
+		}},
+		{"commit fails", &fakeTx{commitErr: errFake}, intRow},
+	}
+	for _, tt := range tests {
+		n, err := BulkInsert(context.Background(), &fakeDB{tx: tt.tx}, "events", []string{"i"}, 7, 3, tt.row)
+		if !errors.Is(err, errFake) || n != 0 {
+			t.Errorf("%s: got %d, %v; want 0 and the failure", tt.name, n, err)
+		}
+		if tt.tx.committed || !tt.tx.rolledBack {
+			t.Errorf("%s: committed=%t rolledBack=%t; want a rollback only", tt.name, tt.tx.committed, tt.tx.rolledBack)
+		}
+	}
+}
+
+func TestBulkInsertBeginFails(t *testing.T) {
+	_, err := BulkInsert(context.Background(), &fakeDB{beginErr: errFake}, "events", []string{"i"}, 1, 1, intRow)
+	if !errors.Is(err, errFake) {
+		t.Errorf("got %v; want the Begin failure", err)
+	}
+}
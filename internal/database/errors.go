@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryAfterSeconds is the Retry-After hint services send with a 503 when
+// IsUnavailable reports the database can't be reached.
+const RetryAfterSeconds = "5"
+
+// IsUnavailable reports whether err means Postgres could not be reached or
+// dropped the connection, as opposed to a fault in the query itself.
+// Handlers use it to answer 503 (retryable) instead of 500.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is "connection exception"; 57P01-57P03 are server
+		// shutdown/startup states and 53300 is too_many_connections.
+		switch {
+		case strings.HasPrefix(pgErr.Code, "08"),
+			pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03",
+			pgErr.Code == "53300":
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// The server closing the socket mid-request surfaces as an EOF
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsTransient reports whether retrying the same statement could succeed:
+// the database was unavailable, or the transaction lost a serialization
+// conflict (40001) or deadlock (40P01) to a concurrent one.
+func IsTransient(err error) bool {
+	if IsUnavailable(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
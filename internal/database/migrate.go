@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migration is one ordered schema change, e.g. an ALTER TABLE.
+type migration struct {
+	version     int
+	description string
+	sql         string
+
+	// data is a one-off data change, e.g. a backfill, run right after sql
+	// in the same transaction the first time only; Reapply skips it for a
+	// recorded migration so it never rewrites a populated table.
+	data string
+}
+
+// migrations are applied in order by Migrate.
+// Only ever append to this list; never edit or reorder an entry that may
+// already have been applied to a deployment. Each sql must be safe to run
+// twice (IF NOT EXISTS and friends) because Reapply re-runs them all.
+var migrations = []migration{
+	{version: 1, description: "create events table", sql: initSQL},
+	{version: 2, description: "add events.Severity", sql: severitySQL, data: severityBackfillSQL},
+}
+
+// severitySQL adds the numeric rank of Level (see models.Severity).
+const severitySQL = `
+ALTER TABLE events ADD COLUMN IF NOT EXISTS Severity SMALLINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS events_severity_timestamp_idx ON events (Severity, Timestamp DESC);
+`
+
+// severityBackfillSQL ranks the rows that predate the Severity column.
+const severityBackfillSQL = `
+UPDATE events SET Severity = CASE upper(trim(Level))
+    WHEN 'DEBUG'   THEN 10
+    WHEN 'INFO'    THEN 20
+    WHEN 'WARN'    THEN 30
+    WHEN 'WARNING' THEN 30
+    WHEN 'ERROR'   THEN 40
+    ELSE 0
+END;
+`
+
+const schemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// migrationLockID is the advisory lock key that serialises Migrate when
+// several services start against the same database at once.
+const migrationLockID = 720_114
+
+// Migrate applies every migration not yet recorded in schema_migrations.
+// Each migration runs in its own transaction together with its version row.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	return migrate(ctx, pool, false)
+}
+
+// Reapply re-runs every migration's schema SQL whether or not it is
+// recorded, to rebuild tables that were dropped by hand while
+// schema_migrations survived. Data changes only run for migrations not yet
+// recorded.
+func Reapply(ctx context.Context, pool *pgxpool.Pool) error {
+	return migrate(ctx, pool, true)
+}
+
+func migrate(ctx context.Context, pool *pgxpool.Pool, force bool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection for migrations: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if _, err := conn.Exec(ctx, schemaMigrationsSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		applied, err := applyMigration(ctx, conn.Conn(), m, force)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+		if applied {
+			log.Printf("Applied migration %d: %s", m.version, m.description)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs m unless it is already recorded (or force is set),
+// reporting whether it ran. m.data only runs when m was not recorded.
+func applyMigration(ctx context.Context, conn *pgx.Conn, m migration, force bool) (bool, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var done bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", m.version,
+	).Scan(&done)
+	if err != nil || (done && !force) {
+		return false, err
+	}
+
+	if _, err := tx.Exec(ctx, m.sql); err != nil {
+		return false, err
+	}
+	if !done && m.data != "" {
+		if _, err := tx.Exec(ctx, m.data); err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT DO NOTHING", m.version); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
@@ -3,9 +3,12 @@ package database
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,19 +23,46 @@ CREATE TABLE IF NOT EXISTS events (
 );
 `
 
-// Connect establishes a pool, pings, and runs init SQL.
+// Connect establishes a pool to POSTGRES_HOST, pings, runs init SQL and
+// warms MinConns.
 func Connect() (*pgxpool.Pool, error) {
-	// Build the connection string (DSN)
-	host := os.Getenv("POSTGRES_HOST")
-	if host == "" {
-		host = "localhost"
+	return ConnectTo(PrimaryHost(), true)
+}
+
+// PrimaryHost is POSTGRES_HOST, or localhost when unset.
+func PrimaryHost() string {
+	if host := os.Getenv("POSTGRES_HOST"); host != "" {
+		return host
+	}
+	return "localhost"
+}
+
+// Addr is host's Postgres address as host:port.
+func Addr(host string) string {
+	return net.JoinHostPort(host, "5432")
+}
+
+// ReadHost is POSTGRES_READ_HOST, for services that only read and can be
+// pointed at a replica, falling back to PrimaryHost. replica reports
+// whether a separate read host was configured; such a host may be
+// read-only, so callers must not run schema changes against it.
+func ReadHost() (host string, replica bool) {
+	if host := os.Getenv("POSTGRES_READ_HOST"); host != "" {
+		return host, host != PrimaryHost()
 	}
+	return PrimaryHost(), false
+}
+
+// ConnectTo is Connect against host. The init SQL only runs when
+// initSchema is set, since a read replica would reject it.
+func ConnectTo(host string, initSchema bool) (*pgxpool.Pool, error) {
+	// Build the connection string (DSN)
 
 	// Correct DSN with fixed user variable and sslmode disabled
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:5432/%s?sslmode=disable",
+	dsn := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
 		os.Getenv("POSTGRES_USER"), // Fixed typo
 		os.Getenv("POSTGRES_PASSWORD"),
-		host,
+		Addr(host),
 		os.Getenv("POSTGRES_DB"),
 	)
 
@@ -51,6 +81,16 @@ func Connect() (*pgxpool.Pool, error) {
 	config.HealthCheckPeriod = 1 * time.Minute
 	// We need to set the connect timeout on the underlying config
 	config.ConnConfig.ConnectTimeout = 10 * time.Second
+	// Event timestamps are stored in UTC; scan them back as UTC too rather
+	// than in the process's local zone
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  "timestamptz",
+			OID:   pgtype.TimestamptzOID,
+			Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC},
+		})
+		return nil
+	}
 
 	// 2. Try to connect to the pool (with retry)
 	var pool *pgxpool.Pool
@@ -69,15 +109,47 @@ func Connect() (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("failed to connect to postgres pool after retries: %w", err)
 	}
 
-	// 3. Run the init SQL on the pool
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// 3. Run the init SQL on the pool (skipped for a read replica)
+	if initSchema {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
 
-	_, err = pool.Exec(ctx, initSQL)
-	if err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to run init sql: %w", err)
+		_, err = pool.Exec(ctx, initSQL)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to run init sql: %w", err)
+		}
+	}
+
+	// 4. Open the warm connections now rather than on the first burst
+	warmCtx, warmCancel := context.WithTimeout(context.Background(), config.ConnConfig.ConnectTimeout)
+	defer warmCancel()
+	if err := warmUp(warmCtx, pool, int(config.MinConns)); err != nil {
+		// Not fatal: the pool still dials lazily on demand
+		fmt.Printf("Pool warmup incomplete: %v\n", err)
 	}
 
+	fmt.Printf("Connected to Postgres at %s\n", host)
 	return pool, nil
-}
\ No newline at end of file
+}
+
+// warmUp holds n connections at once so the pool has to dial and
+// authenticate each of them up front. pgxpool only tops up MinConns in the
+// background, which leaves the first requests paying for connection setup.
+func warmUp(ctx context.Context, pool *pgxpool.Pool, n int) error {
+	conns := make([]*pgxpool.Conn, 0, n)
+	defer func() {
+		for _, c := range conns {
+			c.Release()
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		c, err := pool.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("warmed %d of %d connections: %w", i, n, err)
+		}
+		conns = append(conns, c)
+	}
+	return nil
+}
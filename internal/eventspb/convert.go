@@ -0,0 +1,26 @@
+// Package eventspb holds the Protobuf wire types for events.
+// events.pb.go is generated from events.proto; do not edit it by hand.
+package eventspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative events.proto
+
+import (
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+)
+
+// ToModel converts a Protobuf event into the shared models.Event.
+// A missing timestamp becomes the zero time, same as omitting it in JSON.
+func (e *Event) ToModel() models.Event {
+	var ts time.Time
+	if e.GetTimestamp() != nil {
+		ts = e.GetTimestamp().AsTime()
+	}
+	return models.Event{
+		Timestamp: ts,
+		Level:     e.GetLevel(),
+		Source:    e.GetSource(),
+		Message:   e.GetMessage(),
+	}
+}
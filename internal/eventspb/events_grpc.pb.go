@@ -0,0 +1,120 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: events.proto
+
+package eventspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	EventIngest_IngestEvents_FullMethodName = "/events.v1.EventIngest/IngestEvents"
+)
+
+// EventIngestClient is the client API for EventIngest service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// EventIngest is the streaming alternative to POST /ingest.
+type EventIngestClient interface {
+	// IngestEvents buffers streamed events and writes them in bulk batches.
+	IngestEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Event, IngestSummary], error)
+}
+
+type eventIngestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventIngestClient(cc grpc.ClientConnInterface) EventIngestClient {
+	return &eventIngestClient{cc}
+}
+
+func (c *eventIngestClient) IngestEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Event, IngestSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EventIngest_ServiceDesc.Streams[0], EventIngest_IngestEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Event, IngestSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventIngest_IngestEventsClient = grpc.ClientStreamingClient[Event, IngestSummary]
+
+// EventIngestServer is the server API for EventIngest service.
+// All implementations must embed UnimplementedEventIngestServer
+// for forward compatibility.
+//
+// EventIngest is the streaming alternative to POST /ingest.
+type EventIngestServer interface {
+	// IngestEvents buffers streamed events and writes them in bulk batches.
+	IngestEvents(grpc.ClientStreamingServer[Event, IngestSummary]) error
+	mustEmbedUnimplementedEventIngestServer()
+}
+
+// UnimplementedEventIngestServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEventIngestServer struct{}
+
+func (UnimplementedEventIngestServer) IngestEvents(grpc.ClientStreamingServer[Event, IngestSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method IngestEvents not implemented")
+}
+func (UnimplementedEventIngestServer) mustEmbedUnimplementedEventIngestServer() {}
+func (UnimplementedEventIngestServer) testEmbeddedByValue()                     {}
+
+// UnsafeEventIngestServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventIngestServer will
+// result in compilation errors.
+type UnsafeEventIngestServer interface {
+	mustEmbedUnimplementedEventIngestServer()
+}
+
+func RegisterEventIngestServer(s grpc.ServiceRegistrar, srv EventIngestServer) {
+	// If the following call pancis, it indicates UnimplementedEventIngestServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&EventIngest_ServiceDesc, srv)
+}
+
+func _EventIngest_IngestEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EventIngestServer).IngestEvents(&grpc.GenericServerStream[Event, IngestSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventIngest_IngestEventsServer = grpc.ClientStreamingServer[Event, IngestSummary]
+
+// EventIngest_ServiceDesc is the grpc.ServiceDesc for EventIngest service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EventIngest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "events.v1.EventIngest",
+	HandlerType: (*EventIngestServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "IngestEvents",
+			Handler:       _EventIngest_IngestEvents_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "events.proto",
+}
@@ -0,0 +1,115 @@
+// Package httpx builds the HTTP clients the tools in cmd/ use to call the
+// services, with connection reuse tuned and optional retries.
+package httpx
+
+import (
+	"cmp"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Defaults applied by NewClient to zero Options fields.
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultRetryBackoff        = 100 * time.Millisecond
+)
+
+// Options configures NewClient. Zero values take the defaults above.
+type Options struct {
+	// Timeout bounds a whole request, retries included. 0 means none.
+	Timeout time.Duration
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// Retries is how many times an idempotent request is retried after a
+	// connection error or 5xx. 0, the default, disables retrying.
+	Retries int
+	// RetryBackoff is the wait before the first retry; it doubles after each.
+	RetryBackoff time.Duration
+}
+
+// NewClient returns a client with a pooled transport and, when
+// opts.Retries > 0, a RetryTransport in front of it.
+func NewClient(opts Options) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = cmp.Or(opts.MaxIdleConns, DefaultMaxIdleConns)
+	transport.MaxIdleConnsPerHost = cmp.Or(opts.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost)
+	transport.IdleConnTimeout = cmp.Or(opts.IdleConnTimeout, DefaultIdleConnTimeout)
+
+	var rt http.RoundTripper = transport
+	if opts.Retries > 0 {
+		rt = &RetryTransport{
+			Base:    transport,
+			Retries: opts.Retries,
+			Backoff: cmp.Or(opts.RetryBackoff, DefaultRetryBackoff),
+		}
+	}
+	return &http.Client{Timeout: opts.Timeout, Transport: rt}
+}
+
+// RetryTransport retries idempotent requests that fail to connect or get a
+// 5xx back. Other requests, e.g. a POST to /ingest, are sent exactly once
+// since repeating them could store the same events twice.
+type RetryTransport struct {
+	Base    http.RoundTripper
+	Retries int
+	Backoff time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A body can only be replayed if it can be re-opened
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if !isIdempotent(req) || !replayable {
+		return t.Base.RoundTrip(req)
+	}
+
+	backoff := t.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		if attempt == t.Retries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
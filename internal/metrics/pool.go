@@ -0,0 +1,66 @@
+// Package metrics exposes service internals to Prometheus.
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector reports pgxpool statistics. Stat() is read on every scrape,
+// so the values are always current without a background ticker.
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	totalConns       *prometheus.Desc
+	acquiredConns    *prometheus.Desc
+	idleConns        *prometheus.Desc
+	maxConns         *prometheus.Desc
+	acquireCount     *prometheus.Desc
+	emptyAcquires    *prometheus.Desc
+	emptyAcquireWait *prometheus.Desc
+	acquireDuration  *prometheus.Desc
+}
+
+// NewPoolCollector returns a collector for pool. Register it once per service.
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	return &PoolCollector{
+		pool: pool,
+
+		totalConns:    prometheus.NewDesc("db_pool_open_connections", "Connections currently open, in use or idle.", nil, nil),
+		acquiredConns: prometheus.NewDesc("db_pool_in_use_connections", "Connections currently acquired by callers.", nil, nil),
+		idleConns:     prometheus.NewDesc("db_pool_idle_connections", "Connections open and waiting to be acquired.", nil, nil),
+		maxConns:      prometheus.NewDesc("db_pool_max_connections", "Configured MaxConns.", nil, nil),
+		acquireCount:  prometheus.NewDesc("db_pool_acquires_total", "Successful connection acquires.", nil, nil),
+		emptyAcquires: prometheus.NewDesc("db_pool_wait_count_total",
+			"Acquires that had to wait because no idle connection was available.", nil, nil),
+		emptyAcquireWait: prometheus.NewDesc("db_pool_wait_duration_seconds_total",
+			"Time spent waiting in acquires that found the pool empty.", nil, nil),
+		acquireDuration: prometheus.NewDesc("db_pool_acquire_duration_seconds_total",
+			"Total time spent in successful acquires.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.totalConns
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.emptyAcquires
+	ch <- c.emptyAcquireWait
+	ch <- c.acquireDuration
+}
+
+// Collect implements prometheus.Collector.
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireWait, prometheus.CounterValue, s.EmptyAcquireWaitTime().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
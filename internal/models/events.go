@@ -8,4 +8,8 @@ type Event struct {
 	Level     string    `json:"level"`
 	Source    string    `json:"source"`
 	Message   string    `json:"message"`
-}
\ No newline at end of file
+
+	// ID is an optional client-chosen identifier echoed back in the ingest
+	// acknowledgement. It is not stored.
+	ID string `json:"id,omitempty" db:"-"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "strings"
+
+// Severity ranks for the known levels. Level stays the display value;
+// Severity is stored alongside it so "ERROR and above" is a range filter.
+const (
+	SeverityUnknown = 0
+	SeverityDebug   = 10
+	SeverityInfo    = 20
+	SeverityWarn    = 30
+	SeverityError   = 40
+)
+
+// Severity maps a level to its rank, ignoring case. Unrecognised levels
+// rank as SeverityUnknown.
+func Severity(level string) int16 {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "DEBUG":
+		return SeverityDebug
+	case "INFO":
+		return SeverityInfo
+	case "WARN", "WARNING":
+		return SeverityWarn
+	case "ERROR":
+		return SeverityError
+	default:
+		return SeverityUnknown
+	}
+}
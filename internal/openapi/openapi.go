@@ -0,0 +1,22 @@
+// Package openapi serves the hand-written OpenAPI 3 description of the
+// ingest and query HTTP APIs. Update openapi.json alongside any handler
+// change that adds a route, parameter or response shape.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Handler serves the spec; both services mount it at /openapi.json.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
@@ -0,0 +1,299 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rajindersingh041/go-microservices/internal/models"
+	"github.com/rajindersingh041/go-microservices/internal/query"
+)
+
+// The validator below covers the subset of OpenAPI 3.0 schema keywords
+// openapi.json uses: $ref, type, nullable, enum, oneOf, properties,
+// required, items, additionalProperties, minimum, maximum and the
+// date-time format. An unknown keyword fails the test so the subset is
+// widened rather than silently ignored.
+var knownKeywords = map[string]bool{
+	"$ref": true, "type": true, "nullable": true, "enum": true, "oneOf": true,
+	"properties": true, "required": true, "items": true, "additionalProperties": true,
+	"minimum": true, "maximum": true, "format": true, "default": true,
+	"description": true, "example": true,
+}
+
+func loadSpec(t *testing.T) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return doc
+}
+
+// resolve follows a local "#/a/b" reference.
+func resolve(doc map[string]any, ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var node any = doc
+	for _, part := range strings.Split(ref[2:], "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	m, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("$ref %q is not an object", ref)
+	}
+	return m, nil
+}
+
+// validate checks v, as decoded by encoding/json, against schema.
+func validate(doc, schema map[string]any, v any, path string) error {
+	for k := range schema {
+		if !knownKeywords[k] {
+			return fmt.Errorf("%s: schema keyword %q is not supported by this test", path, k)
+		}
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := resolve(doc, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return validate(doc, target, v, path)
+	}
+
+	if v == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", path)
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, v, enum)
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		var errs []string
+		for _, s := range oneOf {
+			if err := validate(doc, s.(map[string]any), v, path); err != nil {
+				errs = append(errs, err.Error())
+			} else {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matched %d oneOf branches, want 1 (%s)", path, matched, strings.Join(errs, "; "))
+		}
+	}
+
+	switch typ, _ := schema["type"].(string); typ {
+	case "":
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: %T is not an object", path, v)
+		}
+		for _, r := range asSlice(schema["required"]) {
+			if _, ok := obj[r.(string)]; !ok {
+				return fmt.Errorf("%s: missing required %q", path, r)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for k, fv := range obj {
+			if ps, ok := props[k].(map[string]any); ok {
+				if err := validate(doc, ps, fv, path+"."+k); err != nil {
+					return err
+				}
+				continue
+			}
+			switch ap := schema["additionalProperties"].(type) {
+			case map[string]any:
+				if err := validate(doc, ap, fv, path+"."+k); err != nil {
+					return err
+				}
+			case bool:
+				if !ap {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+			case nil:
+				if props != nil {
+					return fmt.Errorf("%s: property %q is not in the schema", path, k)
+				}
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: %T is not an array", path, v)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, e := range arr {
+				if err := validate(doc, items, e, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: %T is not a string", path, v)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", path, s)
+			}
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%s: %T is not a number", path, v)
+		}
+		if typ == "integer" && n != math.Trunc(n) {
+			return fmt.Errorf("%s: %v is not an integer", path, n)
+		}
+		if lo, ok := schema["minimum"].(float64); ok && n < lo {
+			return fmt.Errorf("%s: %v is below the minimum %v", path, n, lo)
+		}
+		if hi, ok := schema["maximum"].(float64); ok && n > hi {
+			return fmt.Errorf("%s: %v is above the maximum %v", path, n, hi)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: %T is not a boolean", path, v)
+		}
+	default:
+		return fmt.Errorf("%s: unknown type %q", path, typ)
+	}
+	return nil
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+// walk calls fn on every object in the document with its JSON path.
+func walk(node any, path string, fn func(obj map[string]any, path string)) {
+	switch n := node.(type) {
+	case map[string]any:
+		fn(n, path)
+		for k, v := range n {
+			walk(v, path+"/"+k, fn)
+		}
+	case []any:
+		for i, v := range n {
+			walk(v, fmt.Sprintf("%s/%d", path, i), fn)
+		}
+	}
+}
+
+// TestExamplesMatchSchemas checks every example against its schema: a
+// media type or parameter example against the sibling schema, a schema
+// example against the schema it sits in.
+func TestExamplesMatchSchemas(t *testing.T) {
+	doc := loadSpec(t)
+	checked := 0
+	walk(doc, "#", func(obj map[string]any, path string) {
+		example, ok := obj["example"]
+		if !ok {
+			return
+		}
+		schema, ok := obj["schema"].(map[string]any)
+		if !ok {
+			schema = obj
+		}
+		checked++
+		if err := validate(doc, schema, example, path); err != nil {
+			t.Errorf("example does not match its schema: %v", err)
+		}
+	})
+	if checked == 0 {
+		t.Fatal("found no examples to check")
+	}
+}
+
+// TestRefsResolve catches a renamed or removed component.
+func TestRefsResolve(t *testing.T) {
+	doc := loadSpec(t)
+	walk(doc, "#", func(obj map[string]any, path string) {
+		if ref, ok := obj["$ref"].(string); ok {
+			if _, err := resolve(doc, ref); err != nil {
+				t.Errorf("%s: %v", path, err)
+			}
+		}
+	})
+}
+
+// TestSchemasMatchGoTypes encodes the shared wire types and validates
+// them against the components that describe them.
+func TestSchemasMatchGoTypes(t *testing.T) {
+	doc := loadSpec(t)
+	event := models.Event{Timestamp: time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), Level: "INFO", Source: "payment-svc", Message: "charge ok", ID: "a1"}
+	tests := []struct {
+		schema string
+		value  any
+	}{
+		{"#/components/schemas/Event", event},
+		{"#/components/schemas/Event", models.Event{Timestamp: event.Timestamp}},
+		{"#/components/schemas/EventPage", query.NewEnvelope([]models.Event{event}, 10, 0, 1, 25)},
+		{"#/components/schemas/EventPage", query.NewEnvelope([]models.Event{}, 10, 20, 0, 20)},
+	}
+	for _, tt := range tests {
+		raw, err := json.Marshal(tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			t.Fatal(err)
+		}
+		schema, err := resolve(doc, tt.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := validate(doc, schema, v, tt.schema); err != nil {
+			t.Errorf("%s does not match %s: %v", raw, tt.schema, err)
+		}
+	}
+}
+
+// TestValidatorRejects guards against a validator that passes anything.
+func TestValidatorRejects(t *testing.T) {
+	doc := loadSpec(t)
+	schema, err := resolve(doc, "#/components/schemas/IngestResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{
+		`{"ingested": 1}`,
+		`{"status": "maybe", "ingested": 1}`,
+		`{"status": "accepted", "ingested": "1"}`,
+		`{"status": "accepted", "ingested": 1, "accepted": [1]}`,
+		`{"status": "accepted", "ingested": 1, "bogus": true}`,
+	} {
+		var v any
+		json.Unmarshal([]byte(body), &v)
+		if validate(doc, schema, v, "IngestResponse") == nil {
+			t.Errorf("%s validated against IngestResponse", body)
+		}
+	}
+}
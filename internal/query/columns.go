@@ -0,0 +1,79 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column is an events column clients may refer to by its field name.
+// Handlers put Name into SQL text only after looking it up here, so a
+// client-supplied string never reaches a statement directly.
+type Column struct {
+	Field string // name clients use, e.g. in ?fields= or a filter
+	Name  string // events column
+	Kind  string // "string", "time" or "number"; what values it compares to
+
+	// Selectable columns may be listed in ?fields=; the rest can only be
+	// filtered on.
+	Selectable bool
+}
+
+// Columns is the whitelist, with the selectable ones in the order a full
+// row is selected.
+var Columns = []Column{
+	{Field: "timestamp", Name: "Timestamp", Kind: "time", Selectable: true},
+	{Field: "level", Name: "Level", Kind: "string", Selectable: true},
+	{Field: "source", Name: "Source", Kind: "string", Selectable: true},
+	{Field: "message", Name: "Message", Kind: "string", Selectable: true},
+	{Field: "severity", Name: "Severity", Kind: "number"},
+}
+
+// LookupColumn finds the column for a field name, ignoring case.
+func LookupColumn(field string) (Column, bool) {
+	field = strings.ToLower(strings.TrimSpace(field))
+	for _, c := range Columns {
+		if c.Field == field {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// FieldNames lists the field names of the columns, selectable ones only
+// when selectable is set, for error messages.
+func FieldNames(selectable bool) string {
+	var names []string
+	for _, c := range Columns {
+		if c.Selectable || !selectable {
+			names = append(names, c.Field)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseFields turns ?fields=a,b into the columns to select. Only selectable
+// names in Columns are accepted; anything else is an *Error. An empty
+// param selects every selectable column and reports projected as false.
+func ParseFields(param string) (columns []string, projected bool, err error) {
+	if param == "" {
+		for _, c := range Columns {
+			if c.Selectable {
+				columns = append(columns, c.Name)
+			}
+		}
+		return columns, false, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(param, ",") {
+		c, ok := LookupColumn(name)
+		if !ok || !c.Selectable {
+			return nil, false, &Error{Param: "fields", Reason: fmt.Sprintf("unknown field %q: must be one of %s", strings.TrimSpace(name), FieldNames(true))}
+		}
+		if !seen[c.Name] {
+			seen[c.Name] = true
+			columns = append(columns, c.Name)
+		}
+	}
+	return columns, true, nil
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		param     string
+		columns   []string
+		projected bool
+	}{
+		{"", []string{"Timestamp", "Level", "Source", "Message"}, false},
+		{"level", []string{"Level"}, true},
+		{"timestamp,message", []string{"Timestamp", "Message"}, true},
+		{" Level , SOURCE ", []string{"Level", "Source"}, true},
+		{"level,level", []string{"Level"}, true},
+	}
+	for _, tt := range tests {
+		columns, projected, err := ParseFields(tt.param)
+		if err != nil {
+			t.Errorf("ParseFields(%q): %v", tt.param, err)
+			continue
+		}
+		if !reflect.DeepEqual(columns, tt.columns) || projected != tt.projected {
+			t.Errorf("ParseFields(%q) = %v, %t; want %v, %t", tt.param, columns, projected, tt.columns, tt.projected)
+		}
+	}
+}
+
+func TestParseFieldsRejectsUnknownNames(t *testing.T) {
+	for _, param := range []string{
+		"1;DROP",
+		"1;DROP TABLE events",
+		"level;DROP TABLE events--",
+		"message,(SELECT 1)",
+		"Timestamp FROM events;--",
+		"*",
+		"id",
+		"severity", // filterable but not selectable
+		"level,",
+	} {
+		columns, _, err := ParseFields(param)
+		var qerr *Error
+		if !errors.As(err, &qerr) || qerr.Param != "fields" {
+			t.Errorf("ParseFields(%q) = %v, %v; want a fields *Error", param, columns, err)
+		}
+	}
+}
+
+func TestLookupColumn(t *testing.T) {
+	for _, field := range []string{"timestamp", "LEVEL", " source ", "severity"} {
+		if _, ok := LookupColumn(field); !ok {
+			t.Errorf("LookupColumn(%q) not found", field)
+		}
+	}
+	for _, field := range []string{"", "id", "Level; DROP TABLE events", "level--", "message OR 1=1"} {
+		if c, ok := LookupColumn(field); ok {
+			t.Errorf("LookupColumn(%q) = %+v; want not found", field, c)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Envelope wraps one page of a list response with its position, for
+// clients that ask with ?envelope=true. Bare arrays stay the default.
+type Envelope struct {
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// Pagination describes where a page sits in the full result.
+type Pagination struct {
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+	Total  int64 `json:"total"`
+	// NextOffset is the offset of the following page, or null on the last
+	NextOffset *int `json:"next_offset"`
+}
+
+// WantsEnvelope reads ?envelope. Any problem is returned as an *Error.
+func WantsEnvelope(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("envelope")
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &Error{Param: "envelope", Reason: fmt.Sprintf("%q is not a boolean", v)}
+	}
+	return b, nil
+}
+
+// NewEnvelope wraps data, a page of returned rows read at offset with the
+// given (already capped) limit, out of total matching rows.
+func NewEnvelope(data interface{}, limit, offset, returned int, total int64) Envelope {
+	e := Envelope{
+		Data:       data,
+		Pagination: Pagination{Limit: limit, Offset: offset, Total: total},
+	}
+	if next := offset + returned; returned > 0 && int64(next) < total {
+		e.Pagination.NextOffset = &next
+	}
+	return e
+}
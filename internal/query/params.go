@@ -0,0 +1,107 @@
+// Package query parses the list-endpoint query parameters shared by the
+// query services, so defaults, bounds and validation live in one place.
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultLimit is used when ?limit is absent
+	DefaultLimit = 10
+	// MaxLimit is the default cap on rows returned. A larger ?limit is
+	// accepted and clamped by the service, which flags the truncation
+	MaxLimit = 1000
+)
+
+// Params are the parsed list parameters. Zero values mean "not given".
+type Params struct {
+	Limit  int
+	Offset int
+	From   time.Time // ?from, RFC3339, inclusive
+	To     time.Time // ?to, RFC3339, inclusive
+	Level  string
+	Source string
+	// MinSeverity is ?min_severity, matched against models.Severity ranks
+	MinSeverity int
+}
+
+// Error reports a single bad parameter. Handlers answer it with 400.
+type Error struct {
+	Param  string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Param, e.Reason)
+}
+
+// ParseParams reads and validates the list parameters from r.
+// Any problem is returned as an *Error.
+func ParseParams(r *http.Request) (Params, error) {
+	// URL.Query silently drops pairs it can't parse, e.g. fields=1;DROP,
+	// which would quietly widen the query instead of failing it
+	q, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return Params{}, &Error{Param: "query string", Reason: err.Error()}
+	}
+	p := Params{
+		Limit:  DefaultLimit,
+		Level:  q.Get("level"),
+		Source: q.Get("source"),
+	}
+
+	if p.Limit, err = intParam(q.Get("limit"), "limit", DefaultLimit, 1, -1); err != nil {
+		return p, err
+	}
+	if p.Offset, err = intParam(q.Get("offset"), "offset", 0, 0, -1); err != nil {
+		return p, err
+	}
+	if p.MinSeverity, err = intParam(q.Get("min_severity"), "min_severity", 0, 0, 255); err != nil {
+		return p, err
+	}
+	if p.From, err = timeParam(q.Get("from"), "from"); err != nil {
+		return p, err
+	}
+	if p.To, err = timeParam(q.Get("to"), "to"); err != nil {
+		return p, err
+	}
+	if !p.From.IsZero() && !p.To.IsZero() && p.From.After(p.To) {
+		return p, &Error{Param: "from", Reason: "must not be after to"}
+	}
+	return p, nil
+}
+
+// intParam parses v, applying def when empty and enforcing [lo, hi].
+// A negative hi means there is no upper bound.
+func intParam(v, name string, def, lo, hi int) (int, error) {
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, &Error{Param: name, Reason: fmt.Sprintf("%q is not an integer", v)}
+	}
+	if n < lo || (hi >= 0 && n > hi) {
+		if hi < 0 {
+			return 0, &Error{Param: name, Reason: fmt.Sprintf("must be at least %d", lo)}
+		}
+		return 0, &Error{Param: name, Reason: fmt.Sprintf("must be between %d and %d", lo, hi)}
+	}
+	return n, nil
+}
+
+func timeParam(v, name string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, &Error{Param: name, Reason: fmt.Sprintf("%q is not an RFC3339 timestamp", v)}
+	}
+	return t, nil
+}
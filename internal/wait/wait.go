@@ -0,0 +1,37 @@
+// Package wait blocks until a dependency accepts connections, so services
+// started together (e.g. by docker-compose) don't race their database.
+package wait
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// pollInterval is the pause between failed dials
+const pollInterval = 500 * time.Millisecond
+
+// WaitForTCP dials addr until a connection succeeds or timeout passes. The
+// probe connection is closed straight away. On timeout the error names
+// addr and carries the last dial failure.
+func WaitForTCP(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, dialTimeout(deadline))
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			return fmt.Errorf("%s did not accept connections within %s: %w", addr, timeout, err)
+		}
+		time.Sleep(min(pollInterval, left))
+	}
+}
+
+// dialTimeout bounds one dial by the time left, and by a second so a
+// blackholed address is retried rather than waited on in one go.
+func dialTimeout(deadline time.Time) time.Duration {
+	return max(min(time.Until(deadline), time.Second), time.Millisecond)
+}